@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/Evernorth/slogx-go/slogx"
+	"github.com/Evernorth/slogx-go/slogx/httpx"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+// This gets us a slog.Logger with context support that logs in JSON format to stdout.
+var (
+	logger, _ = slogx.NewLoggerBuilder().
+		WithWriter(os.Stdout).
+		WithFormat(slogx.FormatJSON).
+		WithLevel(slog.LevelInfo).
+		WithContextHandler().
+		Build()
+)
+
+// main This example demonstrates how to use the httpx middleware to add request-scoped attributes to the context of
+// each request and to log an access line when each request finishes.
+// The logger is configured to log in JSON format to stdout with a default log level of INFO.
+func main() {
+	middleware := httpx.NewMiddlewareBuilder().
+		WithLogger(logger).
+		WithRemoteAddrHeader("X-Forwarded-For").
+		Build()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /hello", func(w http.ResponseWriter, r *http.Request) {
+		// The request ID, method, path and remote address are added to this record from the context
+		logger.InfoContext(r.Context(), "Saying hello")
+		_, _ = w.Write([]byte("Hello, World!\n"))
+	})
+
+	err := http.ListenAndServe(":8080", middleware(mux))
+	if err != nil {
+		panic(err)
+	}
+}
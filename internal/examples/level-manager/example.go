@@ -8,7 +8,7 @@ import (
 
 // Environment variables
 // These can be set to change the log level at runtime.
-// The log level can be set to one of the following values: DEBUG, INFO, WARN, ERROR, FATAL, PANIC
+// The log level can be set to one of the following values: TRACE, DEBUG, INFO, NOTICE, WARN, ERROR, FATAL, PANIC
 const (
 	logger1LevelEnvVar = "LOGGER1_LOG_LEVEL"
 	logger2LevelEnvVar = "LOGGER2_LOG_LEVEL"
@@ -17,46 +17,34 @@ const (
 // Loggers
 // This gets us a slog.Logger with context support that logs in JSON format to stdout.
 var (
-	logger1, levelVar1 = slogx.NewLoggerBuilder().
+	// WithManagedLevel enrolls the levelVars with the default LevelManager
+	logger1, levelVar1 = slogx.NewExtendedLoggerBuilder().
 				WithWriter(os.Stdout).
 				WithFormat(slogx.FormatJSON).
 				WithLevel(slog.LevelInfo).
+				WithLevelEnvVar(logger1LevelEnvVar).
+				WithManagedLevel().
 				Build()
 
-	logger2, levelVar2 = slogx.NewLoggerBuilder().
+	logger2, levelVar2 = slogx.NewExtendedLoggerBuilder().
 				WithWriter(os.Stdout).
 				WithFormat(slogx.FormatJSON).
 				WithLevel(slog.LevelDebug).
+				WithLevelEnvVar(logger2LevelEnvVar).
+				WithManagedLevel().
 				Build()
 )
 
-// manageLevelFromEnv Manage the log level from an environment variable
-func manageLevelFromEnv(logLevel string, levelVar *slog.LevelVar) {
-	// Log the log level
-	slog.Default().Info("", slog.String(logLevel, os.Getenv(logLevel)))
-
-	// Set the log level
-	err := slogx.GetLevelManager().ManageLevelFromEnv(levelVar, logLevel)
-	if err != nil {
-		panic(err)
-	}
-
-}
-
 // setup Set the default level manager
 func setup() {
 
-	// Enroll the levelVars with the LevelManager
-	manageLevelFromEnv(logger1LevelEnvVar, levelVar1)
-	manageLevelFromEnv(logger2LevelEnvVar, levelVar2)
-
 	// Tell the LevelManager to update the levels
 	slogx.GetLevelManager().UpdateLevels()
 
 	// Log that the logger has been initialized
 
-	slog.Info("Logger initialized", slog.String(logger1LevelEnvVar, levelVar1.Level().String()))
-	slog.Info("Logger initialized", slog.String(logger2LevelEnvVar, levelVar2.Level().String()))
+	slog.Info("Logger initialized", slog.String(logger1LevelEnvVar, slogx.LevelName(levelVar1.Level())))
+	slog.Info("Logger initialized", slog.String(logger2LevelEnvVar, slogx.LevelName(levelVar2.Level())))
 
 }
 
@@ -0,0 +1,280 @@
+package slogx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Output names that Config resolves to the standard streams rather than to file paths.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// Config is a declarative logger configuration that can be unmarshalled from JSON or YAML, overridden from environment
+// variables with ApplyEnv, and turned into a logger with Build.  A zero Config builds the same logger as
+// NewLoggerBuilder().Build().
+type Config struct {
+	// Format is "text" or "json".
+	Format Format `json:"format" yaml:"format"`
+
+	// Output is "stdout", "stderr" or the path of a file to append to.  If Output and Outputs are empty, the logger
+	// writes to stderr.
+	Output string `json:"output" yaml:"output"`
+
+	// Outputs are additional outputs, each written to as for Output.
+	Outputs []string `json:"outputs" yaml:"outputs"`
+
+	// Level is the name of the default level, parsed with GetLevelByName.
+	Level string `json:"level" yaml:"level"`
+
+	// LevelKey is the name of an environment variable that overrides Level, as for LoggerBuilder.WithLevelEnvVar.
+	LevelKey string `json:"level_key" yaml:"level_key"`
+
+	// TimestampFormat is a Go time layout for the timestamp, as for LoggerBuilder.WithTimestampFormat.
+	TimestampFormat string `json:"timestamp_format" yaml:"timestamp_format"`
+
+	// ContextHandler enables the ContextHandler, as for LoggerBuilder.WithContextHandler.
+	ContextHandler bool `json:"context_handler" yaml:"context_handler"`
+
+	// AddSource adds the source file and line of the logging call to each record.
+	AddSource bool `json:"add_source" yaml:"add_source"`
+}
+
+// Validate returns a FieldError, named by the JSON key of the field, for every invalid field joined with errors.Join.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Format != FormatText && c.Format != FormatJSON {
+		errs = append(errs, &FieldError{Field: "format", Err: fmt.Errorf("invalid format: %d", int(c.Format))})
+	}
+	for i, output := range c.Outputs {
+		if strings.TrimSpace(output) == "" {
+			errs = append(errs, &FieldError{Field: fmt.Sprintf("outputs[%d]", i), Err: errors.New("output is empty")})
+		}
+	}
+	if c.Level != "" {
+		if _, err := GetLevelByName(c.Level); err != nil {
+			errs = append(errs, &FieldError{Field: "level", Err: err})
+		}
+	}
+	if c.TimestampFormat != "" {
+		if err := validateTimestampFormat(c.TimestampFormat); err != nil {
+			errs = append(errs, &FieldError{Field: "timestamp_format", Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyEnv overrides the fields of the Config from the environment variables with the provided prefix, such as
+// LOG_FORMAT=json for the prefix "LOG".  The variables are <PREFIX>_FORMAT, <PREFIX>_OUTPUT (a comma separated list
+// that replaces Output and Outputs), <PREFIX>_LEVEL, <PREFIX>_LEVEL_KEY, <PREFIX>_TIME_FORMAT,
+// <PREFIX>_CONTEXT_HANDLER and <PREFIX>_ADD_SOURCE.  Unset and empty variables are ignored.  A FieldError, named by
+// the variable, is returned for every invalid value joined with errors.Join.  Valid values are applied even if an
+// error is returned.
+func (c *Config) ApplyEnv(prefix string) error {
+	settings, fieldErrs := readEnvSettings(prefix)
+	if settings.format != nil {
+		c.Format = *settings.format
+	}
+	if settings.outputs != nil {
+		c.Output = ""
+		c.Outputs = settings.outputs
+	}
+	if settings.level != nil {
+		c.Level = *settings.level
+	}
+	if settings.levelKey != nil {
+		c.LevelKey = *settings.levelKey
+	}
+	if settings.timestampFormat != nil {
+		c.TimestampFormat = *settings.timestampFormat
+	}
+	if settings.contextHandler != nil {
+		c.ContextHandler = *settings.contextHandler
+	}
+	if settings.addSource != nil {
+		c.AddSource = *settings.addSource
+	}
+	errs := make([]error, len(fieldErrs))
+	for i, err := range fieldErrs {
+		errs[i] = err
+	}
+	return errors.Join(errs...)
+}
+
+// envSettings are the valid logger settings read from environment variables.  Nil fields are not set.
+type envSettings struct {
+	format          *Format
+	outputs         []string
+	level           *string
+	levelKey        *string
+	timestampFormat *string
+	contextHandler  *bool
+	addSource       *bool
+}
+
+// readEnvSettings reads the logger settings from the environment variables with the provided prefix.  A FieldError,
+// named by the variable, is returned for every invalid value.
+func readEnvSettings(prefix string) (envSettings, []*FieldError) {
+	var settings envSettings
+	var errs []*FieldError
+	lookup := func(name string) (string, string, bool) {
+		key := prefix + "_" + name
+		value := strings.TrimSpace(os.Getenv(key))
+		return key, value, value != ""
+	}
+	parseBool := func(name string) *bool {
+		key, value, ok := lookup(name)
+		if !ok {
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, &FieldError{Field: key, Err: fmt.Errorf("invalid boolean: %q", value)})
+			return nil
+		}
+		return &b
+	}
+
+	if key, value, ok := lookup("FORMAT"); ok {
+		var format Format
+		if err := format.UnmarshalText([]byte(value)); err != nil {
+			errs = append(errs, &FieldError{Field: key, Err: err})
+		} else {
+			settings.format = &format
+		}
+	}
+	if key, value, ok := lookup("OUTPUT"); ok {
+		outputs := strings.Split(value, ",")
+		for i := range outputs {
+			outputs[i] = strings.TrimSpace(outputs[i])
+		}
+		if slices.Contains(outputs, "") {
+			errs = append(errs, &FieldError{Field: key, Err: fmt.Errorf("invalid output list: %q", value)})
+		} else {
+			settings.outputs = outputs
+		}
+	}
+	if key, value, ok := lookup("LEVEL"); ok {
+		if _, err := GetLevelByName(value); err != nil {
+			errs = append(errs, &FieldError{Field: key, Err: err})
+		} else {
+			settings.level = &value
+		}
+	}
+	if _, value, ok := lookup("LEVEL_KEY"); ok {
+		settings.levelKey = &value
+	}
+	if key, value, ok := lookup("TIME_FORMAT"); ok {
+		if err := validateTimestampFormat(value); err != nil {
+			errs = append(errs, &FieldError{Field: key, Err: err})
+		} else {
+			settings.timestampFormat = &value
+		}
+	}
+	settings.contextHandler = parseBool("CONTEXT_HANDLER")
+	settings.addSource = parseBool("ADD_SOURCE")
+
+	return settings, errs
+}
+
+// NewLoggerBuilder validates the Config and returns an ExtendedLoggerBuilder configured from it, to add options that
+// Config does not cover before building.  Output files are opened when the logger is built, and are closed if the build
+// fails.  Call Close on the ExtendedLoggerBuilder to close the output files of the loggers it built.
+func (c *Config) NewLoggerBuilder() (ExtendedLoggerBuilder, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	lb := NewExtendedLoggerBuilder().(*extendedLoggerBuilder)
+	lb.WithFormat(c.Format)
+	var outputs []string
+	if c.Output != "" {
+		outputs = append(outputs, c.Output)
+	}
+	outputs = append(outputs, c.Outputs...)
+	if len(outputs) > 0 {
+		lb.withOutputs("output", outputs)
+	}
+	if c.Level != "" {
+		lb.WithLevelString(c.Level)
+	}
+	if c.LevelKey != "" {
+		lb.WithLevelEnvVar(c.LevelKey)
+	}
+	if c.TimestampFormat != "" {
+		lb.WithTimestampFormat(c.TimestampFormat)
+	}
+	if c.ContextHandler {
+		lb.WithContextHandler()
+	}
+	if c.AddSource {
+		lb.WithAddSource()
+	}
+	return lb, nil
+}
+
+// Build validates the Config and builds a slog.Logger from it.  A slog.LevelVar to control the logger level and an
+// io.Closer that closes the output files of the logger are also returned.  Call Close when the logger is no longer
+// used.  As with ExtendedLoggerBuilder.BuildE, an invalid level name in the LevelKey variable is returned as an error
+// matching ErrInvalidLevelKey together with a logger built at the default level.
+func (c *Config) Build() (*slog.Logger, *slog.LevelVar, io.Closer, error) {
+	lb, err := c.NewLoggerBuilder()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	logger, levelVar, err := lb.BuildE()
+	if logger == nil {
+		return nil, nil, nil, err
+	}
+	return logger, levelVar, lb, err
+}
+
+// openOutputs returns an io.Writer that writes to every output, and the files that were opened for them.  If an
+// output cannot be opened, the files already opened are closed.
+func openOutputs(outputs []string) (io.Writer, []*os.File, error) {
+	writers := make([]io.Writer, 0, len(outputs))
+	var files []*os.File
+	for _, output := range outputs {
+		writer, err := openOutput(output)
+		if err != nil {
+			closeFiles(files)
+			return nil, nil, err
+		}
+		if file, ok := writer.(*os.File); ok && file != os.Stdout && file != os.Stderr {
+			files = append(files, file)
+		}
+		writers = append(writers, writer)
+	}
+	if len(writers) == 1 {
+		return writers[0], files, nil
+	}
+	return io.MultiWriter(writers...), files, nil
+}
+
+// closeFiles closes the files, returning their errors joined with errors.Join.
+func closeFiles(files []*os.File) error {
+	var errs []error
+	for _, file := range files {
+		errs = append(errs, file.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// openOutput returns the standard stream named by output, or opens the file at the output path for appending.
+func openOutput(output string) (io.Writer, error) {
+	switch strings.ToLower(output) {
+	case OutputStdout:
+		return os.Stdout, nil
+	case OutputStderr:
+		return os.Stderr, nil
+	default:
+		return os.OpenFile(output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	}
+}
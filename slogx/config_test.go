@@ -0,0 +1,260 @@
+package slogx
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_UnmarshalJSON(t *testing.T) {
+	var config Config
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"format": "JSON",
+		"output": "stdout",
+		"outputs": ["app.log"],
+		"level": "debug",
+		"level_key": "APP_LOG_LEVEL",
+		"timestamp_format": "15:04:05",
+		"context_handler": true,
+		"add_source": true
+	}`), &config))
+
+	assert.Equal(t, Config{
+		Format:          FormatJSON,
+		Output:          "stdout",
+		Outputs:         []string{"app.log"},
+		Level:           "debug",
+		LevelKey:        "APP_LOG_LEVEL",
+		TimestampFormat: "15:04:05",
+		ContextHandler:  true,
+		AddSource:       true,
+	}, config)
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"format": "xml"}`), &config), `invalid format: "xml"`)
+}
+
+func TestConfig_UnmarshalYAML(t *testing.T) {
+	var config Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+format: json
+outputs:
+  - stderr
+  - app.log
+level: WARN
+timestamp_format: "2006-01-02T15:04:05Z07:00"
+context_handler: true
+`), &config))
+
+	assert.Equal(t, Config{
+		Format:          FormatJSON,
+		Outputs:         []string{"stderr", "app.log"},
+		Level:           "WARN",
+		TimestampFormat: "2006-01-02T15:04:05Z07:00",
+		ContextHandler:  true,
+	}, config)
+
+	assert.ErrorContains(t, yaml.Unmarshal([]byte(`format: xml`), &config), `invalid format: "xml"`)
+}
+
+func TestFormat_MarshalText(t *testing.T) {
+	text, err := json.Marshal(Config{Format: FormatJSON})
+	require.NoError(t, err)
+	assert.Contains(t, string(text), `"format":"json"`)
+
+	_, err = Format(7).MarshalText()
+	assert.Error(t, err)
+	assert.Equal(t, "text", FormatText.String())
+	assert.Equal(t, "Format(7)", Format(7).String())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{}).Validate())
+
+	config := Config{
+		Format:          Format(7),
+		Outputs:         []string{"stdout", " "},
+		Level:           "LOUD",
+		TimestampFormat: "invalid",
+	}
+	err := config.Validate()
+	require.Error(t, err)
+
+	var fields []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fieldErr *FieldError
+		require.ErrorAs(t, e, &fieldErr)
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.Equal(t, []string{"format", "outputs[1]", "level", "timestamp_format"}, fields)
+	assert.ErrorContains(t, err, "level: invalid level name: LOUD")
+
+	_, _, closer, err := config.Build()
+	assert.Error(t, err)
+	assert.Nil(t, closer)
+}
+
+func TestConfig_ApplyEnv(t *testing.T) {
+	t.Setenv("SVC_LOG_FORMAT", "json")
+	t.Setenv("SVC_LOG_OUTPUT", "stdout, app.log")
+	t.Setenv("SVC_LOG_LEVEL", "debug")
+	t.Setenv("SVC_LOG_LEVEL_KEY", "SVC_LEVEL")
+	t.Setenv("SVC_LOG_TIME_FORMAT", "15:04")
+	t.Setenv("SVC_LOG_CONTEXT_HANDLER", "true")
+	t.Setenv("SVC_LOG_ADD_SOURCE", "1")
+
+	config := Config{
+		Output: "stderr",
+		Level:  "info",
+	}
+	require.NoError(t, config.ApplyEnv("SVC_LOG"))
+	assert.Equal(t, Config{
+		Format:          FormatJSON,
+		Outputs:         []string{"stdout", "app.log"},
+		Level:           "debug",
+		LevelKey:        "SVC_LEVEL",
+		TimestampFormat: "15:04",
+		ContextHandler:  true,
+		AddSource:       true,
+	}, config)
+
+	// Unset variables do not change the Config
+	config = Config{Level: "warn"}
+	require.NoError(t, config.ApplyEnv("OTHER_LOG"))
+	assert.Equal(t, Config{Level: "warn"}, config)
+}
+
+func TestConfig_ApplyEnv_Errors(t *testing.T) {
+	t.Setenv("SVC_LOG_FORMAT", "xml")
+	t.Setenv("SVC_LOG_LEVEL", "LOUD")
+	t.Setenv("SVC_LOG_TIME_FORMAT", "invalid")
+	t.Setenv("SVC_LOG_CONTEXT_HANDLER", "yes please")
+	t.Setenv("SVC_LOG_ADD_SOURCE", "true")
+
+	config := Config{Level: "info"}
+	err := config.ApplyEnv("SVC_LOG")
+	assert.ErrorContains(t, err, `SVC_LOG_FORMAT: invalid format: "xml"`)
+	assert.ErrorContains(t, err, "SVC_LOG_LEVEL: invalid level name: LOUD")
+	assert.ErrorContains(t, err, "SVC_LOG_TIME_FORMAT: invalid timestamp format")
+	assert.ErrorContains(t, err, `SVC_LOG_CONTEXT_HANDLER: invalid boolean: "yes please"`)
+
+	// Valid values are applied and invalid values are not
+	assert.Equal(t, Config{Level: "info", AddSource: true}, config)
+}
+
+func TestConfig_Build(t *testing.T) {
+	dir := t.TempDir()
+	path1 := filepath.Join(dir, "app1.log")
+	path2 := filepath.Join(dir, "app2.log")
+	require.NoError(t, os.WriteFile(path1, []byte("existing\n"), 0o600))
+
+	config := Config{
+		Format:          FormatJSON,
+		Output:          path1,
+		Outputs:         []string{path2},
+		Level:           "debug",
+		TimestampFormat: "15:04",
+		ContextHandler:  true,
+		AddSource:       true,
+	}
+	logger, levelVar, closer, err := config.Build()
+	require.NoError(t, err)
+	defer closer.Close()
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+	_, ok := logger.Handler().(*ContextHandler)
+	assert.True(t, ok)
+
+	logger.Debug("configured")
+
+	for _, path := range []string{path1, path2} {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"level":"DEBUG","source":{`)
+		assert.Contains(t, string(content), `"msg":"configured"`)
+	}
+	content, err := os.ReadFile(path1)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "existing\n")
+}
+
+func TestConfig_Build_InvalidLevelKey(t *testing.T) {
+	t.Setenv("CONFIG_INVALID_LEVEL", "LOUD")
+	config := Config{Level: "warn", LevelKey: "CONFIG_INVALID_LEVEL"}
+
+	// The logger is built at the default level, and can be closed
+	logger, levelVar, closer, err := config.Build()
+	assert.ErrorIs(t, err, ErrInvalidLevelKey)
+	require.NotNil(t, logger)
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+	require.NotNil(t, closer)
+	assert.NoError(t, closer.Close())
+}
+
+func TestConfig_Build_OutputError(t *testing.T) {
+	config := Config{Output: filepath.Join(t.TempDir(), "missing", "app.log")}
+	_, _, _, err := config.Build()
+	var fieldErr *FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "output", fieldErr.Field)
+}
+
+func TestConfig_NewLoggerBuilder(t *testing.T) {
+	config := Config{Output: "stdout", Level: "warn"}
+	lb, err := config.NewLoggerBuilder()
+	require.NoError(t, err)
+
+	builder := lb.(*extendedLoggerBuilder)
+	assert.Equal(t, []string{"stdout"}, builder.outputs)
+	assert.Equal(t, slog.LevelWarn, builder.level)
+}
+
+func TestConfig_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	config := Config{Outputs: []string{"stderr", path}}
+	logger, _, closer, err := config.Build()
+	require.NoError(t, err)
+	logger.Info("before close")
+
+	files := closer.(*extendedLoggerBuilder).files
+	require.Len(t, files, 1)
+	require.NoError(t, closer.Close())
+	_, err = files[0].Write([]byte("after close\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+
+	// Copies of the Config build loggers with their own files
+	copied := config
+	_, _, copiedCloser, err := copied.Build()
+	require.NoError(t, err)
+	assert.NotSame(t, closer, copiedCloser)
+	require.NoError(t, copiedCloser.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "before close")
+}
+
+// failingLevelManager is a LevelManager that cannot enroll levels.
+type failingLevelManager struct {
+	LevelManager
+}
+
+func (failingLevelManager) ManageLevelFromEnv(*slog.LevelVar, string) error {
+	return errors.New("enrollment failed")
+}
+
+func TestConfig_Build_ClosesOutputsOnError(t *testing.T) {
+	config := Config{Output: filepath.Join(t.TempDir(), "app.log"), LevelKey: "CONFIG_CLOSE_LEVEL"}
+	lb, err := config.NewLoggerBuilder()
+	require.NoError(t, err)
+
+	// Enrolling the level fails after the output is opened, which is closed and not kept
+	_, _, err = lb.WithLevelManager(failingLevelManager{}).BuildE()
+	assert.EqualError(t, err, "levelManager: enrollment failed")
+	assert.Empty(t, lb.(*extendedLoggerBuilder).files)
+}
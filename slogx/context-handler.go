@@ -3,73 +3,493 @@ package slogx
 import (
 	"context"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 )
 
 type contextAttrsKey struct{}
 
+// MaskedAttrValue is the value logged in place of the value of a masked context attr.
+const MaskedAttrValue = "********"
+
+// contextAttrsOp is the operation a contextAttrs layer applies to the Attrs of its parent.
+type contextAttrsOp int
+
+const (
+	contextAttrsSet contextAttrsOp = iota
+	contextAttrsRemove
+	contextAttrsMask
+)
+
+// contextAttrs is one layer of Attrs stored in a Context.  Each layer holds only the change made by a single
+// ContextWithAttrs, ContextWithGroupAttrs, ContextWithoutAttrs or ContextWithMaskedAttrs call and points to the layer
+// of its parent Context.
+// The full list of Attrs is resolved lazily the first time it is needed and cached, so logging with the same Context
+// repeatedly does not allocate.
+type contextAttrs struct {
+	parent *contextAttrs
+	op     contextAttrsOp
+	group  string      // group that the Attrs of a contextAttrsSet layer are added to, empty for the top level
+	attrs  []slog.Attr // Attrs added by a contextAttrsSet layer
+	keys   []string    // keys removed or masked by a contextAttrsRemove or contextAttrsMask layer
+
+	once     sync.Once
+	resolved []slog.Attr
+	masked   []string // keys masked by this layer or any of its parents
+	removed  []string // keys removed by this layer or any of its parents, and not added again since
+}
+
 // ContextWithAttrs adds one or more slog.Attr objects to the provided Context.  A new Context containing the new
-// Attrs is returned.
+// Attrs is returned.  Attrs are logged in the order they were first added; adding an Attr with an existing key
+// replaces the value in place.
 func ContextWithAttrs(ctx context.Context, newAttrs ...slog.Attr) context.Context {
-	// Get the Attrs map from the Context
-	attrMap := getAttrMap(ctx)
-
-	// Copy the map so we do not modify the original
-	newAttrMap := make(map[string]slog.Attr)
-	for key, value := range *attrMap {
-		newAttrMap[key] = value
+	if len(newAttrs) == 0 {
+		return ctx
 	}
 
-	// Add the new Attrs to the copy of the map
-	for _, newAttr := range newAttrs {
-		newAttrMap[newAttr.Key] = newAttr
+	// Store only the new Attrs, linked to the parent layer
+	return context.WithValue(ctx, contextAttrsKey{}, &contextAttrs{
+		parent: getContextAttrsLayer(ctx),
+		op:     contextAttrsSet,
+		attrs:  slices.Clone(newAttrs),
+	})
+}
+
+// ContextWithGroupAttrs adds one or more slog.Attr objects to the named group in the provided Context.  A new Context
+// containing the new Attrs is returned.  Attrs added to the same group by different calls are merged into a single
+// group, with later calls overriding an existing key in the group in place.  If group is empty, ContextWithGroupAttrs
+// behaves like ContextWithAttrs.
+func ContextWithGroupAttrs(ctx context.Context, group string, newAttrs ...slog.Attr) context.Context {
+	if len(newAttrs) == 0 {
+		return ctx
 	}
+	return context.WithValue(ctx, contextAttrsKey{}, &contextAttrs{
+		parent: getContextAttrsLayer(ctx),
+		op:     contextAttrsSet,
+		group:  group,
+		attrs:  slices.Clone(newAttrs),
+	})
+}
 
-	// Store the copy in the new Context and return it
-	return context.WithValue(ctx, contextAttrsKey{}, &newAttrMap)
+// ContextWithoutAttrs removes the slog.Attr objects with the provided keys from the provided Context.  A new Context
+// without the Attrs is returned.  The Attrs remain in the parent Context.  A key of the form "group.key" removes a
+// member of a group, such as one added with ContextWithGroupAttrs, and a group left without members is removed.
+func ContextWithoutAttrs(ctx context.Context, keys ...string) context.Context {
+	return contextWithKeys(ctx, contextAttrsRemove, keys)
 }
 
-// getAttrMap returns the slog.Attrs map from the provided Context.
-func getAttrMap(ctx context.Context) *map[string]slog.Attr {
+// ContextWithMaskedAttrs marks the slog.Attr objects with the provided keys as masked.  A new Context is returned in
+// which the values of the Attrs are replaced with MaskedAttrValue.  A key remains masked in every Context derived
+// from the returned one, even if a new value is added for it later.  A key of the form "group.key" masks a member of a
+// group, such as one added with ContextWithGroupAttrs.
+func ContextWithMaskedAttrs(ctx context.Context, keys ...string) context.Context {
+	return contextWithKeys(ctx, contextAttrsMask, keys)
+}
+
+// contextWithKeys returns a new Context with a layer that removes or masks the provided keys.
+func contextWithKeys(ctx context.Context, op contextAttrsOp, keys []string) context.Context {
+	if len(keys) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, contextAttrsKey{}, &contextAttrs{
+		parent: getContextAttrsLayer(ctx),
+		op:     op,
+		keys:   slices.Clone(keys),
+	})
+}
 
-	// Read the map from the Context if it exists and return it
+// AttrsFromContext returns the slog.Attr objects that a ContextHandler will add to a record logged with the provided
+// Context, in the order they will be logged.
+func AttrsFromContext(ctx context.Context) []slog.Attr {
+	return slices.Clone(getContextAttrs(ctx))
+}
+
+// getContextAttrsLayer returns the innermost contextAttrs layer from the provided Context, or nil if there is none.
+func getContextAttrsLayer(ctx context.Context) *contextAttrs {
 	value := ctx.Value(contextAttrsKey{})
-	if value != nil {
-		attrMap, ok := value.(*map[string]slog.Attr)
-		if !ok {
-			panic("Could not cast context attrs to *map[string]slog.Attr")
+	if value == nil {
+		return nil
+	}
+	layer, ok := value.(*contextAttrs)
+	if !ok {
+		panic("Could not cast context attrs to *contextAttrs")
+	}
+	return layer
+}
+
+// getContextAttrs returns the ordered slog.Attrs from the provided Context.  The returned slice is shared by every
+// Context derived from the one it was stored in and must not be modified.
+func getContextAttrs(ctx context.Context) []slog.Attr {
+	return getContextAttrsLayer(ctx).resolve()
+}
+
+// resolve returns the Attrs of its parents with the change made by this layer applied.  The result is computed once
+// and cached.
+func (c *contextAttrs) resolve() []slog.Attr {
+	if c == nil {
+		return nil
+	}
+	c.once.Do(func() {
+		parentAttrs := c.parent.resolve()
+		if c.parent != nil {
+			c.masked = c.parent.masked
+			c.removed = c.parent.removed
+		}
+
+		switch c.op {
+		case contextAttrsSet:
+			attrs := make([]slog.Attr, len(parentAttrs), len(parentAttrs)+len(c.attrs))
+			copy(attrs, parentAttrs)
+
+			if c.group == "" {
+				c.resolved = setAttrs(attrs, c.attrs, c.masked)
+				c.removed = withoutKeys(c.removed, func(key string) bool {
+					return indexOfAttr(c.attrs, key) >= 0
+				})
+				break
+			}
+
+			// Merge the Attrs into the existing group, if there is one
+			var members []slog.Attr
+			if i := indexOfAttr(attrs, c.group); i >= 0 && attrs[i].Value.Kind() == slog.KindGroup {
+				members = slices.Clone(attrs[i].Value.Group())
+			}
+			members = setAttrs(members, c.attrs, nil)
+			c.resolved = setAttrs(attrs, []slog.Attr{{Key: c.group, Value: slog.GroupValue(members...)}}, c.masked)
+			c.removed = withoutKeys(c.removed, func(key string) bool {
+				return key == c.group
+			})
+
+		case contextAttrsRemove:
+			c.removed = append(slices.Clip(c.removed), c.keys...)
+			c.resolved = removeAttrs(parentAttrs, c.keys)
+
+		case contextAttrsMask:
+			c.masked = append(slices.Clip(c.masked), c.keys...)
+			attrs := make([]slog.Attr, len(parentAttrs))
+			for i, attr := range parentAttrs {
+				attrs[i] = maskMatchingAttr(attr, c.keys)
+			}
+			c.resolved = attrs
 		}
-		return attrMap
+	})
+	return c.resolved
+}
 
-	} else {
+// setAttrs sets the new Attrs in attrs, overriding existing keys in place and appending new keys.  The values of
+// Attrs and group members with masked keys are replaced with MaskedAttrValue.
+func setAttrs(attrs []slog.Attr, newAttrs []slog.Attr, masked []string) []slog.Attr {
+	for _, attr := range newAttrs {
+		attr = maskMatchingAttr(attr, masked)
+		if i := indexOfAttr(attrs, attr.Key); i >= 0 {
+			attrs[i] = attr
+		} else {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
 
-		// Create a new map and return it if the map does not exist in the Context
-		attrMap := make(map[string]slog.Attr)
-		return &attrMap
+// withoutKeys returns the keys for which del returns false.  The keys slice is only copied if a key is deleted.
+func withoutKeys(keys []string, del func(key string) bool) []string {
+	if !slices.ContainsFunc(keys, del) {
+		return keys
+	}
+	return slices.DeleteFunc(slices.Clone(keys), del)
+}
+
+// removeAttrs returns a copy of attrs without the Attrs with the provided keys.  A key of the form "group.key" removes
+// a member of a group, and a group left without members is removed.
+func removeAttrs(attrs []slog.Attr, keys []string) []slog.Attr {
+	result := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if slices.Contains(keys, attr.Key) {
+			continue
+		}
+		if memberKeys := groupMemberKeys(attr, keys); len(memberKeys) > 0 {
+			members := removeAttrs(attr.Value.Group(), memberKeys)
+			if len(members) == 0 {
+				continue
+			}
+			attr = slog.Attr{Key: attr.Key, Value: slog.GroupValue(members...)}
+		}
+		result = append(result, attr)
+	}
+	return result
+}
+
+// maskMatchingAttr returns the Attr with its value replaced with MaskedAttrValue if its key is one of the provided
+// keys.  A key of the form "group.key" masks a member of a group.
+func maskMatchingAttr(attr slog.Attr, keys []string) slog.Attr {
+	if slices.Contains(keys, attr.Key) {
+		return maskAttr(attr)
+	}
+	memberKeys := groupMemberKeys(attr, keys)
+	if len(memberKeys) == 0 {
+		return attr
 	}
+	members := slices.Clone(attr.Value.Group())
+	for i := range members {
+		members[i] = maskMatchingAttr(members[i], memberKeys)
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(members...)}
+}
+
+// groupMemberKeys returns the keys of the form "group.key" that address the members of a group Attr, with the group
+// prefix removed.  It returns nil if the Attr is not a group.
+func groupMemberKeys(attr slog.Attr, keys []string) []string {
+	if attr.Value.Kind() != slog.KindGroup {
+		return nil
+	}
+	var memberKeys []string
+	for _, key := range keys {
+		if member, ok := strings.CutPrefix(key, attr.Key+"."); ok {
+			memberKeys = append(memberKeys, member)
+		}
+	}
+	return memberKeys
+}
+
+// maskAttr returns a copy of the Attr with its value replaced with MaskedAttrValue.
+func maskAttr(attr slog.Attr) slog.Attr {
+	return slog.String(attr.Key, MaskedAttrValue)
+}
+
+// indexOfAttr returns the index of the Attr with the provided key, or -1 if there is none.
+func indexOfAttr(attrs []slog.Attr, key string) int {
+	for i := range attrs {
+		if attrs[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Keys for the trace attrs added by a ContextHandler.  The names follow the OpenTelemetry log data model.
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// TraceContext identifies the active trace and span of a Context.
+type TraceContext struct {
+	// TraceID is the hex encoded trace ID.
+	TraceID string
+
+	// SpanID is the hex encoded span ID.
+	SpanID string
+
+	// TraceFlags are the W3C trace flags, such as the sampled flag.
+	TraceFlags byte
+}
+
+// TraceContextFunc returns the TraceContext of the active span in the provided Context.  It returns false if the
+// Context does not have a valid span.  A TraceContextFunc allows trace correlation without a dependency on a tracing
+// library; see the otelx package for an OpenTelemetry implementation.
+type TraceContextFunc func(ctx context.Context) (TraceContext, bool)
+
+// BaggageFunc returns the value of the W3C Baggage member with the provided key from the provided Context.  It
+// returns false if there is no such member.  A BaggageFunc allows baggage to be logged without a dependency on a
+// tracing library; see the otelx package for an OpenTelemetry implementation.
+type BaggageFunc func(ctx context.Context, key string) (string, bool)
+
+// ContextHandlerOptions are options for a ContextHandler.  A zero ContextHandlerOptions consists entirely of default
+// values.
+type ContextHandlerOptions struct {
+	// Group is the name of the group that context attrs are added to.  If empty, context attrs are added at the top
+	// level of the record.
+	Group string
+
+	// TraceContextFunc, if set, is used to add the trace_id, span_id and trace_flags attrs of the active span to the
+	// record.  The trace attrs are always added at the top level of the record.
+	TraceContextFunc TraceContextFunc
+
+	// BaggageFunc, if set, is used to add the baggage members with the keys in BaggageKeys to the context attrs.
+	// Baggage members are added as if they were added with ContextWithAttrs before any other context attrs, so
+	// context attrs with the same key take precedence, and ContextWithoutAttrs and ContextWithMaskedAttrs apply to
+	// them too.
+	BaggageFunc BaggageFunc
+
+	// BaggageKeys is the allow-list of baggage member keys added to the context attrs.
+	BaggageKeys []string
 }
 
 // ContextHandler is a slog.Handler that adds slog.Attr objects from the provided Context to the slog.Record.
+// Context attrs are always added at the top level of the record (or under ContextHandlerOptions.Group), regardless
+// of any groups opened with WithGroup.
 type ContextHandler struct {
 	slog.Handler
+	opts ContextHandlerOptions
+
+	// goas holds the groups and attrs added from the first WithGroup call onwards.  They are applied in Handle
+	// instead of being passed to the wrapped handler so that context attrs are not nested inside them.
+	goas []groupOrAttrs
+}
+
+// groupOrAttrs is either a group name or a list of attrs added with WithGroup or WithAttrs.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
 }
 
 // NewContextHandler returns a new ContextHandler that wraps the provided slog.Handler.
 func NewContextHandler(handler slog.Handler) *ContextHandler {
-	return &ContextHandler{
+	return NewContextHandlerWithOptions(handler, nil)
+}
+
+// NewContextHandlerWithOptions returns a new ContextHandler that wraps the provided slog.Handler using the given
+// options.  If opts is nil, the default options are used.
+func NewContextHandlerWithOptions(handler slog.Handler, opts *ContextHandlerOptions) *ContextHandler {
+	h := &ContextHandler{
 		Handler: handler,
 	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// WithAttrs returns a new ContextHandler whose attributes consist of both the receiver's attributes and the
+// arguments.
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	// No groups are open, so the wrapped handler can pre-format the attrs
+	if len(h.goas) == 0 {
+		return &ContextHandler{
+			Handler: h.Handler.WithAttrs(attrs),
+			opts:    h.opts,
+		}
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a new ContextHandler that nests the attributes of subsequent records and WithAttrs calls in the
+// named group.  Context attrs are not affected by the group.
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+// Flush flushes the wrapped handler if it implements Flusher.
+func (h *ContextHandler) Flush() error {
+	if flusher, ok := h.Handler.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
 }
 
+// withGroupOrAttrs returns a copy of the ContextHandler with the provided groupOrAttrs appended.
+func (h *ContextHandler) withGroupOrAttrs(goa groupOrAttrs) *ContextHandler {
+	h2 := *h
+	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
+	copy(h2.goas, h.goas)
+	h2.goas[len(h.goas)] = goa
+	return &h2
+}
+
+// Handle adds the context attrs to the record and passes it to the wrapped handler.
 func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
-	attrMap := *getAttrMap(ctx)
+	attrs := h.contextAttrs(ctx)
+
+	if len(h.goas) > 0 {
+		r = h.nestRecord(r)
+	}
+	h.addContextAttrs(&r, attrs)
+	h.addTraceAttrs(ctx, &r)
 
-	// Convert the map to a slice of Attrs
-	attrs := make([]slog.Attr, 0, len(attrMap))
-	for _, value := range attrMap {
-		attrs = append(attrs, value)
+	return h.Handler.Handle(ctx, r)
+}
+
+// contextAttrs returns the context attrs to add to a record, including any allow-listed baggage members.
+func (h *ContextHandler) contextAttrs(ctx context.Context) []slog.Attr {
+	layer := getContextAttrsLayer(ctx)
+	attrs := layer.resolve()
+	if h.opts.BaggageFunc == nil || len(h.opts.BaggageKeys) == 0 {
+		return attrs
 	}
 
+	var baggageAttrs []slog.Attr
+	for _, key := range h.opts.BaggageKeys {
+		if indexOfAttr(attrs, key) >= 0 || (layer != nil && slices.Contains(layer.removed, key)) {
+			continue
+		}
+		value, ok := h.opts.BaggageFunc(ctx, key)
+		if !ok {
+			continue
+		}
+		attr := slog.String(key, value)
+		if layer != nil && slices.Contains(layer.masked, key) {
+			attr = maskAttr(attr)
+		}
+		baggageAttrs = append(baggageAttrs, attr)
+	}
+	if len(baggageAttrs) == 0 {
+		return attrs
+	}
+	return append(baggageAttrs, attrs...)
+}
+
+// nestRecord returns a copy of the record with its attrs nested inside the groups and attrs held by the handler.
+func (h *ContextHandler) nestRecord(r slog.Record) slog.Record {
+	nested := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		nested = append(nested, a)
+		return true
+	})
+
+	// Work outwards from the innermost group
+	for i := len(h.goas) - 1; i >= 0; i-- {
+		goa := h.goas[i]
+		if goa.group != "" {
+			nested = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(nested...)}}
+		} else {
+			merged := make([]slog.Attr, 0, len(goa.attrs)+len(nested))
+			merged = append(merged, goa.attrs...)
+			nested = append(merged, nested...)
+		}
+	}
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(nested...)
+	return nr
+}
+
+// addContextAttrs adds the context attrs to the record, under the configured group if there is one.
+func (h *ContextHandler) addContextAttrs(r *slog.Record, attrs []slog.Attr) {
+	if len(attrs) == 0 {
+		return
+	}
+	if h.opts.Group != "" {
+		r.AddAttrs(slog.Attr{Key: h.opts.Group, Value: slog.GroupValue(attrs...)})
+		return
+	}
 	r.AddAttrs(attrs...)
+}
 
-	return h.Handler.Handle(ctx, r)
+// addTraceAttrs adds the trace attrs of the active span to the record, if a TraceContextFunc is configured.
+func (h *ContextHandler) addTraceAttrs(ctx context.Context, r *slog.Record) {
+	if h.opts.TraceContextFunc == nil {
+		return
+	}
+	traceContext, ok := h.opts.TraceContextFunc(ctx)
+	if !ok {
+		return
+	}
+	r.AddAttrs(
+		slog.String(TraceIDKey, traceContext.TraceID),
+		slog.String(SpanIDKey, traceContext.SpanID),
+		slog.String(TraceFlagsKey, formatTraceFlags(traceContext.TraceFlags)))
+}
+
+// formatTraceFlags returns the trace flags as two hex digits, as in the W3C traceparent header.
+func formatTraceFlags(flags byte) string {
+	const hexDigits = "0123456789abcdef"
+	return string([]byte{hexDigits[flags>>4], hexDigits[flags&0x0f]})
 }
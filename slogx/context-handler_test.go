@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"log/slog"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -92,3 +95,501 @@ func TestContextLoggerWithUpdate(t *testing.T) {
 	assert.Contains(t, logOutput, "\"test1\":\"new-val1\"")
 	assert.Contains(t, logOutput, "\"test2\":\"val2\"")
 }
+
+func TestContextHandler_WithAttrsAndWithGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		group    string
+		derive   func(logger *slog.Logger) *slog.Logger
+		expected string
+	}{
+		{
+			name:     "no derivation",
+			derive:   func(logger *slog.Logger) *slog.Logger { return logger },
+			expected: `{"msg":"test msg","rec":"r","ctx1":"c"}`,
+		},
+		{
+			name: "with attrs",
+			derive: func(logger *slog.Logger) *slog.Logger {
+				return logger.With("component", "db")
+			},
+			expected: `{"msg":"test msg","component":"db","rec":"r","ctx1":"c"}`,
+		},
+		{
+			name: "with group",
+			derive: func(logger *slog.Logger) *slog.Logger {
+				return logger.WithGroup("g1")
+			},
+			expected: `{"msg":"test msg","g1":{"rec":"r"},"ctx1":"c"}`,
+		},
+		{
+			name: "with attrs then group",
+			derive: func(logger *slog.Logger) *slog.Logger {
+				return logger.With("component", "db").WithGroup("g1")
+			},
+			expected: `{"msg":"test msg","component":"db","g1":{"rec":"r"},"ctx1":"c"}`,
+		},
+		{
+			name: "with group then attrs",
+			derive: func(logger *slog.Logger) *slog.Logger {
+				return logger.WithGroup("g1").With("component", "db")
+			},
+			expected: `{"msg":"test msg","g1":{"component":"db","rec":"r"},"ctx1":"c"}`,
+		},
+		{
+			name: "nested groups and attrs",
+			derive: func(logger *slog.Logger) *slog.Logger {
+				return logger.With("a", 1).WithGroup("g1").With("b", 2).WithGroup("g2").With("c", 3)
+			},
+			expected: `{"msg":"test msg","a":1,"g1":{"b":2,"g2":{"c":3,"rec":"r"}},"ctx1":"c"}`,
+		},
+		{
+			name: "empty group name is ignored",
+			derive: func(logger *slog.Logger) *slog.Logger {
+				return logger.WithGroup("").With("component", "db")
+			},
+			expected: `{"msg":"test msg","component":"db","rec":"r","ctx1":"c"}`,
+		},
+		{
+			name:  "context group",
+			group: "ctx",
+			derive: func(logger *slog.Logger) *slog.Logger {
+				return logger.WithGroup("g1").With("component", "db")
+			},
+			expected: `{"msg":"test msg","g1":{"component":"db","rec":"r"},"ctx":{"ctx1":"c"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := bytes.NewBufferString("")
+			handler := NewContextHandlerWithOptions(newTestJSONHandler(buffer), &ContextHandlerOptions{
+				Group: tt.group,
+			})
+			logger := tt.derive(slog.New(handler))
+
+			ctx := ContextWithAttrs(context.Background(), slog.String("ctx1", "c"))
+			logger.InfoContext(ctx, "test msg", slog.String("rec", "r"))
+
+			assert.Equal(t, tt.expected+"\n", buffer.String())
+		})
+	}
+}
+
+func TestContextHandler_WithGroupWithoutRecordAttrs(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger := slog.New(NewContextHandler(newTestJSONHandler(buffer))).WithGroup("g1")
+
+	ctx := ContextWithAttrs(context.Background(), slog.String("ctx1", "c"))
+	logger.InfoContext(ctx, "test msg")
+
+	assert.Equal(t, `{"msg":"test msg","ctx1":"c"}`+"\n", buffer.String())
+}
+
+func TestContextHandler_DerivedHandlerType(t *testing.T) {
+	handler := NewContextHandler(newTestJSONHandler(bytes.NewBufferString("")))
+
+	assert.IsType(t, &ContextHandler{}, handler.WithAttrs([]slog.Attr{slog.String("a", "b")}))
+	assert.IsType(t, &ContextHandler{}, handler.WithGroup("g1"))
+	assert.IsType(t, &ContextHandler{}, handler.WithGroup("g1").WithAttrs([]slog.Attr{slog.String("a", "b")}))
+}
+
+func TestContextLoggerWithContextGroup(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger, _ := NewExtendedLoggerBuilder().
+		WithWriter(buffer).
+		WithFormat(FormatJSON).
+		WithContextGroup("ctx").
+		Build()
+
+	ctx := ContextWithAttrs(context.Background(), slog.String("test1", "val1"))
+	logger.With("component", "db").InfoContext(ctx, "test msg")
+
+	assert.Contains(t, buffer.String(), `"component":"db","ctx":{"test1":"val1"}`)
+}
+
+// newTestJSONHandler returns a JSON handler that omits the time and level so that the output is deterministic.
+func newTestJSONHandler(buffer *bytes.Buffer) slog.Handler {
+	return slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+func TestContextLoggerAttrOrder(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger, _ := NewLoggerBuilder().
+		WithWriter(buffer).
+		WithContextHandler().
+		Build()
+
+	ctx := ContextWithAttrs(context.Background(),
+		slog.String("z", "1"),
+		slog.String("a", "2"),
+		slog.String("m", "3"))
+	ctx = ContextWithAttrs(ctx,
+		slog.String("b", "4"),
+		slog.String("a", "new-2"))
+
+	// Log repeatedly to catch any nondeterminism
+	for i := 0; i < 20; i++ {
+		logger.InfoContext(ctx, "test msg")
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		assert.True(t, strings.HasSuffix(line, `msg="test msg" z=1 a=new-2 m=3 b=4`), line)
+	}
+}
+
+func TestContextWithAttrsDoesNotModifyParent(t *testing.T) {
+	parent := ContextWithAttrs(context.Background(), slog.String("a", "1"), slog.String("b", "2"))
+	child := ContextWithAttrs(parent, slog.String("a", "new-1"), slog.String("c", "3"))
+
+	assert.Equal(t, []slog.Attr{slog.String("a", "1"), slog.String("b", "2")}, getContextAttrs(parent))
+	assert.Equal(t, []slog.Attr{slog.String("a", "new-1"), slog.String("b", "2"), slog.String("c", "3")},
+		getContextAttrs(child))
+}
+
+func TestContextWithAttrsNoAttrs(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, ctx, ContextWithAttrs(ctx))
+}
+
+func TestContextWithAttrsConcurrentResolve(t *testing.T) {
+	ctx := ContextWithAttrs(context.Background(), slog.String("a", "1"))
+	ctx = ContextWithAttrs(ctx, slog.String("b", "2"), slog.String("a", "new-1"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, []slog.Attr{slog.String("a", "new-1"), slog.String("b", "2")}, getContextAttrs(ctx))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestContextWithAttrsCopiesNewAttrs(t *testing.T) {
+	attrs := []slog.Attr{slog.String("a", "1")}
+	ctx := ContextWithAttrs(context.Background(), attrs...)
+	attrs[0] = slog.String("a", "changed")
+
+	assert.Equal(t, []slog.Attr{slog.String("a", "1")}, getContextAttrs(ctx))
+}
+
+func BenchmarkContextHandler_Handle(b *testing.B) {
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(io.Discard, nil)))
+
+	ctx := ContextWithAttrs(context.Background(),
+		slog.String("request_id", "abc123"),
+		slog.String("method", "GET"))
+	ctx = ContextWithAttrs(ctx,
+		slog.String("user", "u1"),
+		slog.Int("attempt", 1))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.InfoContext(ctx, "test msg")
+	}
+}
+
+func BenchmarkContextWithAttrs(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		ctx = ContextWithAttrs(ctx, slog.Int("key"+strconv.Itoa(i), i))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ContextWithAttrs(ctx, slog.String("request_id", "abc123"))
+	}
+}
+
+func TestContextWithoutAttrs(t *testing.T) {
+	ctx := ContextWithAttrs(context.Background(),
+		slog.String("user_id", "u1"),
+		slog.String("user_email", "u1@example.com"),
+		slog.String("request_id", "r1"))
+	withoutCtx := ContextWithoutAttrs(ctx, "user_email", "not_present")
+
+	assert.Equal(t, []slog.Attr{slog.String("user_id", "u1"), slog.String("request_id", "r1")},
+		AttrsFromContext(withoutCtx))
+	assert.Len(t, AttrsFromContext(ctx), 3)
+
+	// A removed key can be added again
+	readdedCtx := ContextWithAttrs(withoutCtx, slog.String("user_email", "u2@example.com"))
+	assert.Equal(t, []slog.Attr{
+		slog.String("user_id", "u1"),
+		slog.String("request_id", "r1"),
+		slog.String("user_email", "u2@example.com"),
+	}, AttrsFromContext(readdedCtx))
+
+	assert.Equal(t, withoutCtx, ContextWithoutAttrs(withoutCtx))
+}
+
+func TestContextWithMaskedAttrs(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger, _ := NewLoggerBuilder().
+		WithWriter(buffer).
+		WithFormat(FormatJSON).
+		WithContextHandler().
+		Build()
+
+	ctx := ContextWithAttrs(context.Background(),
+		slog.String("user_id", "u1"),
+		slog.String("user_email", "u1@example.com"))
+	maskedCtx := ContextWithMaskedAttrs(ctx, "user_email", "password")
+
+	logger.InfoContext(maskedCtx, "test msg")
+	assert.Contains(t, buffer.String(), `"user_id":"u1","user_email":"********"`)
+	assert.NotContains(t, buffer.String(), "u1@example.com")
+
+	// Masked keys stay masked when a new value is added later
+	updatedCtx := ContextWithAttrs(maskedCtx,
+		slog.String("user_email", "u2@example.com"),
+		slog.String("password", "secret"))
+	assert.Equal(t, []slog.Attr{
+		slog.String("user_id", "u1"),
+		slog.String("user_email", MaskedAttrValue),
+		slog.String("password", MaskedAttrValue),
+	}, AttrsFromContext(updatedCtx))
+
+	// The parent Context is not masked
+	assert.Equal(t, slog.String("user_email", "u1@example.com"), AttrsFromContext(ctx)[1])
+}
+
+func TestAttrsFromContext(t *testing.T) {
+	assert.Empty(t, AttrsFromContext(context.Background()))
+
+	ctx := ContextWithAttrs(context.Background(), slog.String("a", "1"))
+	attrs := AttrsFromContext(ctx)
+	attrs[0] = slog.String("a", "changed")
+
+	assert.Equal(t, []slog.Attr{slog.String("a", "1")}, AttrsFromContext(ctx))
+}
+
+func TestContextWithGroupAttrs(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger := slog.New(NewContextHandler(newTestJSONHandler(buffer)))
+
+	ctx := ContextWithAttrs(context.Background(), slog.String("request_id", "r1"))
+	ctx = ContextWithGroupAttrs(ctx, "http", slog.String("method", "GET"))
+	ctx = ContextWithAttrs(ctx, slog.String("user_id", "u1"))
+	ctx = ContextWithGroupAttrs(ctx, "http",
+		slog.String("route", "/orders/{id}"),
+		slog.String("method", "POST"))
+
+	logger.InfoContext(ctx, "test msg")
+
+	assert.Equal(t,
+		`{"msg":"test msg","request_id":"r1","http":{"method":"POST","route":"/orders/{id}"},"user_id":"u1"}`+"\n",
+		buffer.String())
+}
+
+func TestContextWithGroupAttrsDoesNotModifyParent(t *testing.T) {
+	parent := ContextWithGroupAttrs(context.Background(), "http", slog.String("method", "GET"))
+	child := ContextWithGroupAttrs(parent, "http", slog.String("method", "POST"), slog.Int("status", 200))
+
+	assert.Equal(t, []slog.Attr{slog.Group("http", slog.String("method", "GET"))}, AttrsFromContext(parent))
+	assert.Equal(t, []slog.Attr{slog.Group("http", slog.String("method", "POST"), slog.Int("status", 200))},
+		AttrsFromContext(child))
+}
+
+func TestContextWithGroupAttrsReplacesNonGroup(t *testing.T) {
+	ctx := ContextWithAttrs(context.Background(), slog.String("http", "plain"))
+	ctx = ContextWithGroupAttrs(ctx, "http", slog.String("method", "GET"))
+
+	assert.Equal(t, []slog.Attr{slog.Group("http", slog.String("method", "GET"))}, AttrsFromContext(ctx))
+}
+
+func TestContextWithGroupAttrsEmptyGroup(t *testing.T) {
+	ctx := ContextWithGroupAttrs(context.Background(), "", slog.String("method", "GET"))
+	assert.Equal(t, []slog.Attr{slog.String("method", "GET")}, AttrsFromContext(ctx))
+
+	assert.Equal(t, ctx, ContextWithGroupAttrs(ctx, "http"))
+}
+
+func TestContextWithGroupAttrsRemoveAndMask(t *testing.T) {
+	ctx := ContextWithGroupAttrs(context.Background(), "http", slog.String("method", "GET"))
+	ctx = ContextWithGroupAttrs(ctx, "user", slog.String("email", "u1@example.com"))
+
+	assert.Equal(t, []slog.Attr{slog.Group("user", slog.String("email", "u1@example.com"))},
+		AttrsFromContext(ContextWithoutAttrs(ctx, "http")))
+
+	maskedCtx := ContextWithGroupAttrs(ContextWithMaskedAttrs(ctx, "user"), "user", slog.String("name", "u1"))
+	assert.Equal(t, []slog.Attr{
+		slog.Group("http", slog.String("method", "GET")),
+		slog.String("user", MaskedAttrValue),
+	}, AttrsFromContext(maskedCtx))
+}
+
+func TestContextWithGroupAttrsRemoveAndMaskMembers(t *testing.T) {
+	ctx := ContextWithAttrs(context.Background(), slog.String("http.method", "GET"))
+	ctx = ContextWithGroupAttrs(ctx, "user", slog.String("id", "u1"), slog.String("email", "u1@example.com"))
+	ctx = ContextWithGroupAttrs(ctx, "http", slog.String("request_id", "r1"))
+
+	// A group qualified key masks a member of the group, including values added to the group later
+	maskedCtx := ContextWithMaskedAttrs(ctx, "user.email")
+	maskedCtx = ContextWithGroupAttrs(maskedCtx, "user", slog.String("email", "u2@example.com"))
+	assert.Equal(t, []slog.Attr{
+		slog.String("http.method", "GET"),
+		slog.Group("user", slog.String("id", "u1"), slog.String("email", MaskedAttrValue)),
+		slog.Group("http", slog.String("request_id", "r1")),
+	}, AttrsFromContext(maskedCtx))
+
+	// A group qualified key removes a member, and a group left without members is removed.  A top level key that
+	// contains a dot is matched as is.
+	assert.Equal(t, []slog.Attr{
+		slog.Group("user", slog.String("id", "u1")),
+	}, AttrsFromContext(ContextWithoutAttrs(ctx, "user.email", "http.request_id", "http.method")))
+
+	// The parent Context is not changed
+	assert.Equal(t, slog.Group("user", slog.String("id", "u1"), slog.String("email", "u1@example.com")),
+		AttrsFromContext(ctx)[1])
+}
+
+type fakeSpanKey struct{}
+
+// fakeTraceContextFunc returns the TraceContext stored in the Context by the test.
+func fakeTraceContextFunc(ctx context.Context) (TraceContext, bool) {
+	traceContext, ok := ctx.Value(fakeSpanKey{}).(TraceContext)
+	return traceContext, ok
+}
+
+func TestContextHandler_TraceContext(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	handler := NewContextHandlerWithOptions(newTestJSONHandler(buffer), &ContextHandlerOptions{
+		Group:            "ctx",
+		TraceContextFunc: fakeTraceContextFunc,
+	})
+	logger := slog.New(handler).WithGroup("g1")
+
+	ctx := context.WithValue(context.Background(), fakeSpanKey{}, TraceContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		TraceFlags: 0x01,
+	})
+	ctx = ContextWithAttrs(ctx, slog.String("ctx1", "c"))
+	logger.InfoContext(ctx, "test msg", slog.String("rec", "r"))
+
+	assert.Equal(t, `{"msg":"test msg","g1":{"rec":"r"},"ctx":{"ctx1":"c"},`+
+		`"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}`+"\n",
+		buffer.String())
+}
+
+func TestContextHandler_NoTraceContext(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger, _ := NewExtendedLoggerBuilder().
+		WithWriter(buffer).
+		WithFormat(FormatJSON).
+		WithTraceContext(fakeTraceContextFunc).
+		Build()
+
+	logger.InfoContext(context.Background(), "test msg")
+
+	assert.NotContains(t, buffer.String(), TraceIDKey)
+}
+
+func TestFormatTraceFlags(t *testing.T) {
+	assert.Equal(t, "00", formatTraceFlags(0x00))
+	assert.Equal(t, "01", formatTraceFlags(0x01))
+	assert.Equal(t, "0a", formatTraceFlags(0x0a))
+	assert.Equal(t, "ff", formatTraceFlags(0xff))
+}
+
+type fakeBaggageKey struct{}
+
+// fakeBaggageFunc returns the baggage member from the map stored in the Context by the test.
+func fakeBaggageFunc(ctx context.Context, key string) (string, bool) {
+	members, _ := ctx.Value(fakeBaggageKey{}).(map[string]string)
+	value, ok := members[key]
+	return value, ok
+}
+
+func TestContextHandler_Baggage(t *testing.T) {
+	baggageCtx := context.WithValue(context.Background(), fakeBaggageKey{}, map[string]string{
+		"tenant":    "t1",
+		"flag.beta": "on",
+		"secret":    "s1",
+		"ignored":   "not allow-listed",
+	})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{
+			name:     "baggage only",
+			ctx:      baggageCtx,
+			expected: `{"msg":"test msg","tenant":"t1","flag.beta":"on","secret":"s1"}`,
+		},
+		{
+			name:     "no baggage",
+			ctx:      ContextWithAttrs(context.Background(), slog.String("a", "1")),
+			expected: `{"msg":"test msg","a":"1"}`,
+		},
+		{
+			name:     "baggage before context attrs",
+			ctx:      ContextWithAttrs(baggageCtx, slog.String("a", "1")),
+			expected: `{"msg":"test msg","tenant":"t1","flag.beta":"on","secret":"s1","a":"1"}`,
+		},
+		{
+			name:     "context attrs override baggage",
+			ctx:      ContextWithAttrs(baggageCtx, slog.String("tenant", "t2")),
+			expected: `{"msg":"test msg","flag.beta":"on","secret":"s1","tenant":"t2"}`,
+		},
+		{
+			name:     "removed baggage",
+			ctx:      ContextWithoutAttrs(baggageCtx, "flag.beta"),
+			expected: `{"msg":"test msg","tenant":"t1","secret":"s1"}`,
+		},
+		{
+			name:     "removed baggage added again",
+			ctx:      ContextWithAttrs(ContextWithoutAttrs(baggageCtx, "flag.beta"), slog.String("flag.beta", "off")),
+			expected: `{"msg":"test msg","tenant":"t1","secret":"s1","flag.beta":"off"}`,
+		},
+		{
+			name:     "masked baggage",
+			ctx:      ContextWithMaskedAttrs(baggageCtx, "secret"),
+			expected: `{"msg":"test msg","tenant":"t1","flag.beta":"on","secret":"********"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := bytes.NewBufferString("")
+			handler := NewContextHandlerWithOptions(newTestJSONHandler(buffer), &ContextHandlerOptions{
+				BaggageFunc: fakeBaggageFunc,
+				BaggageKeys: []string{"tenant", "flag.beta", "secret", "missing"},
+			})
+
+			slog.New(handler).InfoContext(tt.ctx, "test msg")
+
+			assert.Equal(t, tt.expected+"\n", buffer.String())
+		})
+	}
+}
+
+func TestContextLoggerWithBaggage(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger, _ := NewExtendedLoggerBuilder().
+		WithWriter(buffer).
+		WithFormat(FormatJSON).
+		WithContextGroup("ctx").
+		WithBaggage(fakeBaggageFunc, "tenant").
+		Build()
+
+	ctx := context.WithValue(context.Background(), fakeBaggageKey{}, map[string]string{"tenant": "t1"})
+	ctx = ContextWithAttrs(ctx, slog.String("a", "1"))
+	logger.InfoContext(ctx, "test msg")
+
+	assert.Contains(t, buffer.String(), `"ctx":{"tenant":"t1","a":"1"}`)
+}
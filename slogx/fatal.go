@@ -0,0 +1,185 @@
+package slogx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Flusher is implemented by handlers that buffer records and by writers that buffer output, such as bufio.Writer.
+// The Fatal and Panic functions flush the handler of the logger before exiting or panicking.
+type Flusher interface {
+	Flush() error
+}
+
+// exitState holds the exit function and shutdown hooks used by the Fatal functions.
+var exitState = struct {
+	sync.Mutex
+	exitFunc      func(code int)
+	shutdownHooks []func()
+}{
+	exitFunc: os.Exit,
+}
+
+// SetExitFunc sets the function the Fatal functions call to exit the process, and returns the previous one.  The
+// default is os.Exit.  Tests can use SetExitFunc to intercept the exit.
+func SetExitFunc(exitFunc func(code int)) func(code int) {
+	exitState.Lock()
+	defer exitState.Unlock()
+
+	previous := exitState.exitFunc
+	exitState.exitFunc = exitFunc
+	return previous
+}
+
+// RegisterShutdownHook registers a function that the Fatal functions run before exiting the process.  Hooks are run
+// in the reverse order of registration.
+func RegisterShutdownHook(hook func()) {
+	exitState.Lock()
+	defer exitState.Unlock()
+
+	exitState.shutdownHooks = append(exitState.shutdownHooks, hook)
+}
+
+// Fatal logs the message at LevelFatal, flushes the handler of the logger, runs the shutdown hooks, flushes the handler
+// again and exits the process with exit code 1.  If logger is nil, slog.Default() is used.
+func Fatal(logger *slog.Logger, msg string, args ...any) {
+	logAt(context.Background(), logger, LevelFatal, msg, args...)
+	exit(logger)
+}
+
+// FatalContext is like Fatal, but logs with the provided Context.
+func FatalContext(ctx context.Context, logger *slog.Logger, msg string, args ...any) {
+	logAt(ctx, logger, LevelFatal, msg, args...)
+	exit(logger)
+}
+
+// Fatalf is like Fatal, but formats the message with fmt.Sprintf.
+func Fatalf(logger *slog.Logger, format string, args ...any) {
+	logAt(context.Background(), logger, LevelFatal, fmt.Sprintf(format, args...))
+	exit(logger)
+}
+
+// Panic logs the message at LevelPanic, flushes the handler of the logger and panics with the message.  Shutdown
+// hooks are not run, because the panic may be recovered.  If logger is nil, slog.Default() is used.
+func Panic(logger *slog.Logger, msg string, args ...any) {
+	logAt(context.Background(), logger, LevelPanic, msg, args...)
+	flush(logger)
+	panic(msg)
+}
+
+// PanicContext is like Panic, but logs with the provided Context.
+func PanicContext(ctx context.Context, logger *slog.Logger, msg string, args ...any) {
+	logAt(ctx, logger, LevelPanic, msg, args...)
+	flush(logger)
+	panic(msg)
+}
+
+// Panicf is like Panic, but formats the message with fmt.Sprintf.
+func Panicf(logger *slog.Logger, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	logAt(context.Background(), logger, LevelPanic, msg)
+	flush(logger)
+	panic(msg)
+}
+
+// logAt logs the message at the provided level with the caller of the exported function as the source.
+func logAt(ctx context.Context, logger *slog.Logger, level slog.Level, msg string, args ...any) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	// Skip runtime.Callers, logAt and the exported function
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = logger.Handler().Handle(ctx, r)
+}
+
+// flush flushes the handler of the logger if it implements Flusher.
+func flush(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if flusher, ok := logger.Handler().(Flusher); ok {
+		_ = flusher.Flush()
+	}
+}
+
+// exit flushes the handler of the logger, runs the shutdown hooks, flushes the handler again so that anything the
+// hooks logged is written, and calls the exit function.
+func exit(logger *slog.Logger) {
+	flush(logger)
+
+	exitState.Lock()
+	hooks := make([]func(), len(exitState.shutdownHooks))
+	copy(hooks, exitState.shutdownHooks)
+	exitFunc := exitState.exitFunc
+	exitState.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		runShutdownHook(hooks[i])
+	}
+	flush(logger)
+	exitFunc(1)
+}
+
+// runShutdownHook runs a shutdown hook, recovering from any panic so that the remaining hooks run.
+func runShutdownHook(hook func()) {
+	defer func() {
+		_ = recover()
+	}()
+	hook()
+}
+
+// flushHandler is a slog.Handler that flushes the writer of the wrapped handler.
+type flushHandler struct {
+	slog.Handler
+	flusher Flusher
+}
+
+func (h *flushHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &flushHandler{Handler: h.Handler.WithAttrs(attrs), flusher: h.flusher}
+}
+
+func (h *flushHandler) WithGroup(name string) slog.Handler {
+	return &flushHandler{Handler: h.Handler.WithGroup(name), flusher: h.flusher}
+}
+
+// Flush flushes the writer.
+func (h *flushHandler) Flush() error {
+	return h.flusher.Flush()
+}
+
+// syncWriter is a writer that buffers output, whose writes and flushes hold the same mutex so that a flush does not
+// race with a write.
+type syncWriter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writer.Write(p)
+}
+
+// Flush flushes the writer if it implements Flusher.
+func (w *syncWriter) Flush() error {
+	flusher, ok := w.writer.(Flusher)
+	if !ok {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return flusher.Flush()
+}
@@ -0,0 +1,189 @@
+package slogx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// HELPERS
+
+// interceptExit replaces the exit function and shutdown hooks for the duration of the test and returns a pointer to
+// the exit code, which is -1 until the exit function is called.
+func interceptExit(t *testing.T) *int {
+	exitCode := -1
+	previous := SetExitFunc(func(code int) {
+		exitCode = code
+	})
+
+	exitState.Lock()
+	previousHooks := exitState.shutdownHooks
+	exitState.shutdownHooks = nil
+	exitState.Unlock()
+
+	t.Cleanup(func() {
+		SetExitFunc(previous)
+		exitState.Lock()
+		exitState.shutdownHooks = previousHooks
+		exitState.Unlock()
+	})
+	return &exitCode
+}
+
+// TESTS
+
+func TestFatal(t *testing.T) {
+	exitCode := interceptExit(t)
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	logger, _ := NewLoggerBuilder().
+		WithWriter(writer).
+		WithFormat(FormatJSON).
+		WithContextHandler().
+		Build()
+
+	var order []string
+	RegisterShutdownHook(func() {
+		// The log line has been flushed before the hooks run
+		assert.Contains(t, buf.String(), `"level":"FATAL","msg":"cannot start","port":8080`)
+		order = append(order, "first")
+	})
+	RegisterShutdownHook(func() {
+		order = append(order, "second")
+		panic("hook failed")
+	})
+
+	ctx := ContextWithAttrs(context.Background(), slog.String("service", "orders"))
+	FatalContext(ctx, logger.With("port", 8080), "cannot start")
+
+	assert.Equal(t, 1, *exitCode)
+	assert.Equal(t, []string{"second", "first"}, order)
+	assert.Contains(t, buf.String(), `"service":"orders"`)
+}
+
+func TestFatal_HookLogs(t *testing.T) {
+	interceptExit(t)
+
+	var buf bytes.Buffer
+	logger, _ := NewLoggerBuilder().WithWriter(bufio.NewWriter(&buf)).Build()
+	RegisterShutdownHook(func() {
+		logger.Info("closing connections")
+	})
+
+	Fatal(logger, "cannot start")
+
+	// What the hooks log is flushed before exiting
+	assert.Contains(t, buf.String(), `msg="closing connections"`)
+}
+
+func TestFlush_Concurrent(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := NewLoggerBuilder().WithWriter(bufio.NewWriterSize(&buf, 64)).Build()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Info("concurrent", "j", j)
+				flush(logger)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 400, strings.Count(buf.String(), "msg=concurrent"))
+}
+
+func TestFatalf(t *testing.T) {
+	exitCode := interceptExit(t)
+
+	var buf bytes.Buffer
+	logger, _ := NewLoggerBuilder().WithWriter(&buf).Build()
+
+	Fatalf(logger, "cannot open %s", "config.yaml")
+
+	assert.Equal(t, 1, *exitCode)
+	assert.Contains(t, buf.String(), `level=FATAL msg="cannot open config.yaml"`)
+}
+
+func TestFatal_Source(t *testing.T) {
+	interceptExit(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true}))
+
+	Fatal(logger, "cannot start")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	source, ok := entry["source"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "github.com/Evernorth/slogx-go/slogx.TestFatal_Source", source["function"])
+}
+
+func TestFatal_LevelDisabled(t *testing.T) {
+	exitCode := interceptExit(t)
+
+	var buf bytes.Buffer
+	logger, _ := NewLoggerBuilder().WithWriter(&buf).WithLevel(LevelPanic).Build()
+
+	Fatal(logger, "cannot start")
+
+	// The process exits even if the level is disabled
+	assert.Equal(t, 1, *exitCode)
+	assert.Empty(t, buf.String())
+}
+
+func TestPanic(t *testing.T) {
+	exitCode := interceptExit(t)
+	hookRan := false
+	RegisterShutdownHook(func() {
+		hookRan = true
+	})
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	logger, _ := NewLoggerBuilder().WithWriter(writer).Build()
+
+	assert.PanicsWithValue(t, "invariant violated", func() {
+		Panic(logger, "invariant violated", "id", 1)
+	})
+	assert.Contains(t, buf.String(), `level=PANIC msg="invariant violated" id=1`)
+
+	assert.PanicsWithValue(t, "invariant 2 violated", func() {
+		Panicf(logger, "invariant %d violated", 2)
+	})
+	assert.PanicsWithValue(t, "invariant violated", func() {
+		PanicContext(context.Background(), logger, "invariant violated")
+	})
+
+	assert.Equal(t, -1, *exitCode)
+	assert.False(t, hookRan)
+}
+
+func TestContextHandler_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	logger, _ := NewLoggerBuilder().WithWriter(writer).WithContextHandler().Build()
+
+	logger.With("a", 1).WithGroup("g").Info("test msg")
+	assert.Empty(t, buf.String())
+
+	flusher, ok := logger.With("a", 1).WithGroup("g").Handler().(Flusher)
+	require.True(t, ok)
+	require.NoError(t, flusher.Flush())
+	assert.Contains(t, buf.String(), "test msg")
+
+	// A ContextHandler without a flushable handler does nothing
+	assert.NoError(t, NewContextHandler(slog.NewTextHandler(&buf, nil)).Flush())
+}
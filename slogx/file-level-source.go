@@ -0,0 +1,370 @@
+package slogx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default values for FileLevelSourceOptions.
+const (
+	DefaultFileLevelSourceDebounce     = 100 * time.Millisecond
+	DefaultFileLevelSourcePollInterval = time.Second
+)
+
+// FileLevelSourceOptions are options for a FileLevelSource.  A zero FileLevelSourceOptions consists entirely of
+// default values.
+type FileLevelSourceOptions struct {
+	// LevelManager is updated when the file changes.  If nil, GetLevelManager() is used.
+	LevelManager LevelManager
+
+	// Debounce is how long to wait for further changes after the file changes before reloading it.  If zero,
+	// DefaultFileLevelSourceDebounce is used.
+	Debounce time.Duration
+
+	// PollInterval is how often the file is checked for changes when it cannot be watched with inotify.  If zero,
+	// DefaultFileLevelSourcePollInterval is used.
+	PollInterval time.Duration
+
+	// Poll makes the FileLevelSource check the file for changes every PollInterval even when inotify is available.
+	Poll bool
+
+	// OnError is called when the changed file cannot be read or parsed.  The previously loaded levels are kept.  If
+	// nil, the error is logged with slog.Default().
+	OnError func(err error)
+}
+
+// FileLevelSource reads level names from a file that maps keys to level names, and watches it for changes.  Files
+// with a .json, .yaml or .yml extension are parsed as JSON or YAML objects, where nested objects produce dot
+// separated keys such as "payments.ledger".  Other files are parsed as .env style KEY=VALUE lines.  Use the LevelFunc
+// with LevelManager.ManageLevelFromFunc, then call Start to reload the file and call LevelManager.UpdateLevels
+// automatically when it changes.  Changes are detected with inotify on Linux, and by polling on other platforms.
+type FileLevelSource interface {
+	LevelFunc() LevelFunc
+	Load() error
+	Start(ctx context.Context) error
+	Stop()
+}
+
+// defaultFileLevelSource is the default implementation of FileLevelSource.
+type defaultFileLevelSource struct {
+	path   string
+	opts   FileLevelSourceOptions
+	levels atomic.Pointer[map[string]string]
+
+	mu      sync.Mutex
+	content []byte
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewFileLevelSource creates a new FileLevelSource for the file at the provided path and loads it.  An error is
+// returned if the file cannot be read or parsed.  If opts is nil, the default options are used.
+func NewFileLevelSource(path string, opts *FileLevelSourceOptions) (FileLevelSource, error) {
+	s := &defaultFileLevelSource{
+		path: path,
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.LevelManager == nil {
+		s.opts.LevelManager = GetLevelManager()
+	}
+	if s.opts.Debounce <= 0 {
+		s.opts.Debounce = DefaultFileLevelSourceDebounce
+	}
+	if s.opts.PollInterval <= 0 {
+		s.opts.PollInterval = DefaultFileLevelSourcePollInterval
+	}
+	if s.opts.OnError == nil {
+		s.opts.OnError = func(err error) {
+			slog.Default().Warn("Could not reload level file.",
+				slog.String("path", path),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	if err := s.Load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LevelFunc returns a LevelFunc that returns the level name for a key from the most recently loaded file.
+func (s *defaultFileLevelSource) LevelFunc() LevelFunc {
+	return func(key string) string {
+		return (*s.levels.Load())[key]
+	}
+}
+
+// Load reads and parses the file.  If the file cannot be read or parsed, an error is returned and the previously
+// loaded levels are kept.
+func (s *defaultFileLevelSource) Load() error {
+	_, err := s.load()
+	return err
+}
+
+// load reads and parses the file, and reports whether its content changed since it was last loaded.
+func (s *defaultFileLevelSource) load() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	if s.content != nil && bytes.Equal(content, s.content) {
+		return false, nil
+	}
+
+	levels, err := parseLevelFile(s.path, content)
+	if err != nil {
+		return false, fmt.Errorf("could not parse level file %s: %w", s.path, err)
+	}
+	s.levels.Store(&levels)
+	s.content = content
+	return true, nil
+}
+
+// Start starts watching the file in a new goroutine.  When the file changes, it is reloaded and
+// LevelManager.UpdateLevels is called.  Watching stops when the Context is cancelled or Stop is called.
+func (s *defaultFileLevelSource) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done != nil {
+		return errors.New("file level source is already started")
+	}
+
+	var watcher fileWatcher
+	if !s.opts.Poll {
+		// Fall back to polling if the file cannot be watched
+		watcher, _ = newNotifyWatcher(s.path)
+	}
+	if watcher == nil {
+		watcher = newPollWatcher(s.path, s.opts.PollInterval)
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go s.watch(ctx, watcher, s.done)
+	return nil
+}
+
+// Stop stops watching the file and waits for the watching goroutine to finish.
+func (s *defaultFileLevelSource) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// watch reloads the file when the watcher reports a change and no further change is reported for the debounce
+// duration.  If the watcher fails, the error is reported to OnError and the file is polled instead.
+func (s *defaultFileLevelSource) watch(ctx context.Context, watcher fileWatcher, done chan struct{}) {
+	defer close(done)
+	defer func() {
+		_ = watcher.Close()
+	}()
+
+	// Reload once in case the file changed before it was watched
+	debounce := time.NewTimer(s.opts.Debounce)
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-watcher.Events():
+			if !ok {
+				s.opts.OnError(fmt.Errorf("could not watch level file %s, polling it instead: %w", s.path,
+					watcher.Err()))
+				_ = watcher.Close()
+				watcher = newPollWatcher(s.path, s.opts.PollInterval)
+			}
+			debounce.Reset(s.opts.Debounce)
+		case <-debounce.C:
+			s.reload()
+		}
+	}
+}
+
+// reload loads the file and updates the levels if it changed.
+func (s *defaultFileLevelSource) reload() {
+	changed, err := s.load()
+	if err != nil {
+		s.opts.OnError(err)
+		return
+	}
+	if changed {
+		s.opts.LevelManager.UpdateLevels()
+	}
+}
+
+// parseLevelFile parses the content of a level file based on the extension of its path.
+func parseLevelFile(path string, content []byte) (map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var values map[string]any
+		if err := json.Unmarshal(content, &values); err != nil {
+			return nil, err
+		}
+		return flattenLevels(values)
+	case ".yaml", ".yml":
+		var values map[string]any
+		if err := yaml.Unmarshal(content, &values); err != nil {
+			return nil, err
+		}
+		return flattenLevels(values)
+	default:
+		return parseEnvLevels(content)
+	}
+}
+
+// flattenLevels converts nested objects to a map of dot separated keys to level names.  Numeric levels, e.g. -4 or 8,
+// are converted to level names.
+func flattenLevels(values map[string]any) (map[string]string, error) {
+	levels := make(map[string]string)
+	var flatten func(prefix string, values map[string]any) error
+	flatten = func(prefix string, values map[string]any) error {
+		for key, value := range values {
+			switch v := value.(type) {
+			case string:
+				levels[prefix+key] = v
+			case int, int64, uint64:
+				levels[prefix+key] = fmt.Sprint(v)
+			case float64:
+				if v != math.Trunc(v) {
+					return fmt.Errorf("value of %s%s is not an integer level", prefix, key)
+				}
+				levels[prefix+key] = strconv.FormatFloat(v, 'f', -1, 64)
+			case map[string]any:
+				if err := flatten(prefix+key+".", v); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("value of %s%s is not a level name or an object", prefix, key)
+			}
+		}
+		return nil
+	}
+	if err := flatten("", values); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// parseEnvLevels parses .env style KEY=VALUE lines.  Blank lines, comments starting with # and an export prefix are
+// allowed, and values may be quoted.
+func parseEnvLevels(content []byte) (map[string]string, error) {
+	levels := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d is not a KEY=VALUE pair", lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		levels[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// fileWatcher reports possible changes to a watched file.  If the watcher fails, the Events channel is closed and Err
+// returns the error.
+type fileWatcher interface {
+	Events() <-chan struct{}
+	Err() error
+	Close() error
+}
+
+// pollWatcher is a fileWatcher that checks the modification time and size of the file on an interval.
+type pollWatcher struct {
+	events chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// newPollWatcher returns a pollWatcher for the file at the provided path.
+func newPollWatcher(path string, interval time.Duration) *pollWatcher {
+	w := &pollWatcher{
+		events: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	last, lastErr := os.Stat(path)
+	go w.poll(path, interval, last, lastErr)
+	return w
+}
+
+func (w *pollWatcher) poll(path string, interval time.Duration, last os.FileInfo, lastErr error) {
+	defer close(w.done)
+	defer close(w.events)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if changed := (err == nil) != (lastErr == nil) ||
+				(err == nil && (!info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size())); changed {
+				select {
+				case w.events <- struct{}{}:
+				default:
+				}
+			}
+			last, lastErr = info, err
+		}
+	}
+}
+
+func (w *pollWatcher) Events() <-chan struct{} {
+	return w.events
+}
+
+// Err returns nil, because a pollWatcher only stops when it is closed.
+func (w *pollWatcher) Err() error {
+	return nil
+}
+
+func (w *pollWatcher) Close() error {
+	close(w.stop)
+	<-w.done
+	return nil
+}
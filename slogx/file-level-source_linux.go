@@ -0,0 +1,80 @@
+package slogx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// inotifyWatcher is a fileWatcher that uses inotify to watch the directory of the file.  The directory is watched,
+// rather than the file, so that files replaced by a rename, as editors and Kubernetes ConfigMap volumes do, are
+// still watched.
+type inotifyWatcher struct {
+	file   *os.File
+	events chan struct{}
+	done   chan struct{}
+	err    error
+}
+
+// newNotifyWatcher returns an inotifyWatcher for the file at the provided path.
+func newNotifyWatcher(path string) (fileWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	const mask = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_CREATE | syscall.IN_DELETE |
+		syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), mask); err != nil {
+		_ = syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	// The non-blocking file descriptor is registered with the runtime poller, so Close unblocks Read
+	w := &inotifyWatcher{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go w.read()
+	return w, nil
+}
+
+// read reports an event for every read from the inotify file descriptor until it is closed or a read fails.
+func (w *inotifyWatcher) read() {
+	defer close(w.done)
+	defer close(w.events)
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.err = err
+			}
+			return
+		}
+		if n > 0 {
+			select {
+			case w.events <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (w *inotifyWatcher) Events() <-chan struct{} {
+	return w.events
+}
+
+// Err returns the error that stopped the watcher.  It is only valid after the Events channel is closed.
+func (w *inotifyWatcher) Err() error {
+	return w.err
+}
+
+func (w *inotifyWatcher) Close() error {
+	err := w.file.Close()
+	<-w.done
+	return err
+}
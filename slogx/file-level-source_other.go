@@ -0,0 +1,10 @@
+//go:build !linux
+
+package slogx
+
+import "errors"
+
+// newNotifyWatcher returns an error, because inotify is only available on Linux.  Files are polled instead.
+func newNotifyWatcher(path string) (fileWatcher, error) {
+	return nil, errors.ErrUnsupported
+}
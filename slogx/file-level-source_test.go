@@ -0,0 +1,239 @@
+package slogx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLevelFile writes content to a file named name in a temporary directory and returns its path.
+func writeLevelFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// replaceLevelFile atomically replaces the file at path with content, like editors and ConfigMap volumes do.
+func replaceLevelFile(t *testing.T, path string, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte(content), 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func TestFileLevelSource_Formats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name:    "json",
+			file:    "levels.json",
+			content: `{"FILE_LEVEL": "DEBUG", "payments": {"ledger": "WARN"}}`,
+		},
+		{
+			name:    "yaml",
+			file:    "levels.yaml",
+			content: "FILE_LEVEL: DEBUG\npayments:\n  ledger: WARN\n",
+		},
+		{
+			name:    "yml",
+			file:    "levels.yml",
+			content: "FILE_LEVEL: DEBUG\npayments.ledger: WARN\n",
+		},
+		{
+			name:    "env",
+			file:    "levels.env",
+			content: "# levels\n\nFILE_LEVEL=DEBUG\nexport payments.ledger = \"WARN\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewFileLevelSource(writeLevelFile(t, tt.file, tt.content), nil)
+			require.NoError(t, err)
+
+			levelFunc := source.LevelFunc()
+			assert.Equal(t, "DEBUG", levelFunc("FILE_LEVEL"))
+			assert.Equal(t, "WARN", levelFunc("payments.ledger"))
+			assert.Equal(t, "", levelFunc("MISSING"))
+		})
+	}
+}
+
+func TestFileLevelSource_NumericLevels(t *testing.T) {
+	for _, file := range []string{"levels.json", "levels.yaml"} {
+		t.Run(file, func(t *testing.T) {
+			source, err := NewFileLevelSource(writeLevelFile(t, file, `{"FILE_LEVEL": -4, "payments": {"ledger": 8}}`),
+				nil)
+			require.NoError(t, err)
+
+			levelFunc := source.LevelFunc()
+			assert.Equal(t, "-4", levelFunc("FILE_LEVEL"))
+			assert.Equal(t, "8", levelFunc("payments.ledger"))
+
+			level, err := GetLevelByName(levelFunc("FILE_LEVEL"))
+			require.NoError(t, err)
+			assert.Equal(t, slog.LevelDebug, *level)
+		})
+	}
+}
+
+func TestFileLevelSource_Errors(t *testing.T) {
+	_, err := NewFileLevelSource(filepath.Join(t.TempDir(), "missing.json"), nil)
+	assert.Error(t, err)
+
+	_, err = NewFileLevelSource(writeLevelFile(t, "levels.json", `{"FILE_LEVEL": true}`), nil)
+	assert.Error(t, err)
+
+	_, err = NewFileLevelSource(writeLevelFile(t, "levels.json", `{"FILE_LEVEL": 1.5}`), nil)
+	assert.Error(t, err)
+
+	_, err = NewFileLevelSource(writeLevelFile(t, "levels.yaml", "- DEBUG\n"), nil)
+	assert.Error(t, err)
+
+	_, err = NewFileLevelSource(writeLevelFile(t, "levels.env", "FILE_LEVEL\n"), nil)
+	assert.Error(t, err)
+}
+
+func TestFileLevelSource_Load(t *testing.T) {
+	path := writeLevelFile(t, "levels.env", "FILE_LEVEL=DEBUG\n")
+	source, err := NewFileLevelSource(path, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("FILE_LEVEL=ERROR\n"), 0o600))
+	require.NoError(t, source.Load())
+	assert.Equal(t, "ERROR", source.LevelFunc()("FILE_LEVEL"))
+
+	// A malformed file keeps the previous levels
+	require.NoError(t, os.WriteFile(path, []byte("FILE_LEVEL\n"), 0o600))
+	assert.Error(t, source.Load())
+	assert.Equal(t, "ERROR", source.LevelFunc()("FILE_LEVEL"))
+}
+
+func TestFileLevelSource_Watch(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		t.Run(map[bool]string{false: "notify", true: "poll"}[poll], func(t *testing.T) {
+			path := writeLevelFile(t, "levels.json", `{"FILE_WATCH_LEVEL": "INFO"}`)
+
+			var errorCount atomic.Int32
+			source, err := NewFileLevelSource(path, &FileLevelSourceOptions{
+				Debounce:     10 * time.Millisecond,
+				PollInterval: 10 * time.Millisecond,
+				Poll:         poll,
+				OnError: func(err error) {
+					errorCount.Add(1)
+				},
+			})
+			require.NoError(t, err)
+
+			levelVar := &slog.LevelVar{}
+			require.NoError(t, GetLevelManager().ManageLevelFromFunc(levelVar, "FILE_WATCH_LEVEL", source.LevelFunc()))
+			GetLevelManager().UpdateLevels()
+			assert.Equal(t, slog.LevelInfo, levelVar.Level())
+
+			require.NoError(t, source.Start(context.Background()))
+			defer source.Stop()
+			assert.Error(t, source.Start(context.Background()))
+
+			require.NoError(t, os.WriteFile(path, []byte(`{"FILE_WATCH_LEVEL": "DEBUG"}`), 0o600))
+			assert.Eventually(t, func() bool {
+				return levelVar.Level() == slog.LevelDebug
+			}, 5*time.Second, 5*time.Millisecond)
+
+			replaceLevelFile(t, path, `{"FILE_WATCH_LEVEL": "ERROR", "padding": "x"}`)
+			assert.Eventually(t, func() bool {
+				return levelVar.Level() == slog.LevelError
+			}, 5*time.Second, 5*time.Millisecond)
+
+			// A malformed file is reported and the previous level is kept
+			require.NoError(t, os.WriteFile(path, []byte(`{"FILE_WATCH_LEVEL": `), 0o600))
+			assert.Eventually(t, func() bool {
+				return errorCount.Load() > 0
+			}, 5*time.Second, 5*time.Millisecond)
+			assert.Equal(t, slog.LevelError, levelVar.Level())
+		})
+	}
+}
+
+// failedWatcher is a fileWatcher that has failed.
+type failedWatcher struct {
+	events chan struct{}
+}
+
+func (w *failedWatcher) Events() <-chan struct{} {
+	return w.events
+}
+
+func (w *failedWatcher) Err() error {
+	return errors.New("read failed")
+}
+
+func (w *failedWatcher) Close() error {
+	return nil
+}
+
+func TestFileLevelSource_WatchFails(t *testing.T) {
+	path := writeLevelFile(t, "levels.json", `{"FILE_FAILED_LEVEL": "INFO"}`)
+
+	errs := make(chan error, 1)
+	source, err := NewFileLevelSource(path, &FileLevelSourceOptions{
+		Debounce:     10 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+		OnError: func(err error) {
+			errs <- err
+		},
+	})
+	require.NoError(t, err)
+
+	// The failure is reported and the file is polled instead
+	watcher := &failedWatcher{events: make(chan struct{})}
+	close(watcher.events)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go source.(*defaultFileLevelSource).watch(ctx, watcher, done)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "polling it instead: read failed")
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the watcher failure was not reported")
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"FILE_FAILED_LEVEL": "DEBUG", "padding": "x"}`), 0o600))
+	assert.Eventually(t, func() bool {
+		return source.LevelFunc()("FILE_FAILED_LEVEL") == "DEBUG"
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestFileLevelSource_Stop(t *testing.T) {
+	source, err := NewFileLevelSource(writeLevelFile(t, "levels.env", "FILE_LEVEL=DEBUG\n"), nil)
+	require.NoError(t, err)
+
+	// Stopping a source that is not started does nothing
+	source.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, source.Start(ctx))
+	cancel()
+	source.Stop()
+	source.Stop()
+
+	// A stopped source can be started again
+	require.NoError(t, source.Start(context.Background()))
+	source.Stop()
+}
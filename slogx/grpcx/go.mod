@@ -0,0 +1,22 @@
+module github.com/Evernorth/slogx-go/slogx/grpcx
+
+go 1.24.0
+
+require (
+	github.com/Evernorth/slogx-go v1.1.1-0.20261017054935-31970fbe099b
+	github.com/stretchr/testify v1.10.0
+	google.golang.org/grpc v1.73.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/Evernorth/slogx-go => ../..
@@ -0,0 +1,346 @@
+/*
+Package grpcx provides gRPC integrations for slogx.  It is a separate module so that the slogx module does not depend
+on gRPC.
+
+See documentation: https://pkg.go.dev/github.com/Evernorth/slogx-go
+*/
+package grpcx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Evernorth/slogx-go/slogx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AttrKeys are the keys of the attrs added by the interceptors.  An empty key disables the attr.
+type AttrKeys struct {
+	Method   string
+	Peer     string
+	Code     string
+	Duration string
+	Error    string
+}
+
+// DefaultAttrKeys returns the default AttrKeys.
+func DefaultAttrKeys() AttrKeys {
+	return AttrKeys{
+		Method:   "grpc_method",
+		Peer:     "peer_addr",
+		Code:     "grpc_code",
+		Duration: "duration",
+		Error:    "error",
+	}
+}
+
+// CodeLevelFunc returns the level to log the call outcome at for the provided gRPC status code.
+type CodeLevelFunc func(code codes.Code) slog.Level
+
+// DefaultCodeLevel logs successful calls at LevelInfo, calls that failed because of the caller at LevelWarn and calls
+// that failed because of the server at LevelError.
+func DefaultCodeLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.ResourceExhausted, codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unauthenticated:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// Interceptors are the gRPC interceptors built by an InterceptorBuilder.
+type Interceptors struct {
+	UnaryServer  grpc.UnaryServerInterceptor
+	StreamServer grpc.StreamServerInterceptor
+	UnaryClient  grpc.UnaryClientInterceptor
+	StreamClient grpc.StreamClientInterceptor
+}
+
+// InterceptorBuilder builds gRPC server and client interceptors that seed the call Context with call-scoped attrs
+// using slogx.ContextWithAttrs and log the outcome of each call.  Use the interceptors with a logger built with
+// LoggerBuilder.WithContextHandler so that the attrs are added to every record logged with the call Context.
+type InterceptorBuilder interface {
+	WithLogger(logger *slog.Logger) InterceptorBuilder
+	WithMetadataKeys(keys ...string) InterceptorBuilder
+	WithAttrKeys(attrKeys AttrKeys) InterceptorBuilder
+	WithGroup(group string) InterceptorBuilder
+	WithCodeLevelFunc(codeLevelFunc CodeLevelFunc) InterceptorBuilder
+	WithMessage(message string) InterceptorBuilder
+	Build() Interceptors
+}
+
+type defaultInterceptorBuilder struct {
+	logger        *slog.Logger
+	metadataKeys  []string
+	attrKeys      AttrKeys
+	group         string
+	codeLevelFunc CodeLevelFunc
+	message       string
+}
+
+// NewInterceptorBuilder creates a new InterceptorBuilder with default values.  The default values are:
+// logger=slog.Default(), metadataKeys=none, attrKeys=DefaultAttrKeys(), group="", codeLevelFunc=DefaultCodeLevel and
+// message="gRPC call".
+func NewInterceptorBuilder() InterceptorBuilder {
+	return &defaultInterceptorBuilder{
+		logger:        nil,
+		metadataKeys:  nil,
+		attrKeys:      DefaultAttrKeys(),
+		group:         "",
+		codeLevelFunc: DefaultCodeLevel,
+		message:       "gRPC call",
+	}
+}
+
+// WithLogger sets the logger used to log the call outcome.  If not set, slog.Default() at the time of the call is
+// used.
+func (ib *defaultInterceptorBuilder) WithLogger(logger *slog.Logger) InterceptorBuilder {
+	ib.logger = logger
+	return ib
+}
+
+// WithMetadataKeys sets the allow-list of metadata keys added to the Context.  Server interceptors read the incoming
+// metadata and client interceptors read the outgoing metadata.  Multiple values of a key are joined with a comma.
+func (ib *defaultInterceptorBuilder) WithMetadataKeys(keys ...string) InterceptorBuilder {
+	ib.metadataKeys = make([]string, len(keys))
+	for i, key := range keys {
+		// Metadata keys are always lowercase
+		ib.metadataKeys[i] = strings.ToLower(key)
+	}
+	return ib
+}
+
+// WithAttrKeys sets the keys of the attrs added by the interceptors.
+func (ib *defaultInterceptorBuilder) WithAttrKeys(attrKeys AttrKeys) InterceptorBuilder {
+	ib.attrKeys = attrKeys
+	return ib
+}
+
+// WithGroup adds the call-scoped attrs to the Context under the named group using slogx.ContextWithGroupAttrs.
+func (ib *defaultInterceptorBuilder) WithGroup(group string) InterceptorBuilder {
+	ib.group = group
+	return ib
+}
+
+// WithCodeLevelFunc sets the function that selects the level of the call outcome from the gRPC status code.
+func (ib *defaultInterceptorBuilder) WithCodeLevelFunc(codeLevelFunc CodeLevelFunc) InterceptorBuilder {
+	ib.codeLevelFunc = codeLevelFunc
+	return ib
+}
+
+// WithMessage sets the message of the call outcome.
+func (ib *defaultInterceptorBuilder) WithMessage(message string) InterceptorBuilder {
+	ib.message = message
+	return ib
+}
+
+// Build creates the interceptors with the provided configuration.
+func (ib *defaultInterceptorBuilder) Build() Interceptors {
+	i := *ib
+	return Interceptors{
+		UnaryServer:  i.unaryServer,
+		StreamServer: i.streamServer,
+		UnaryClient:  i.unaryClient,
+		StreamClient: i.streamClient,
+	}
+}
+
+// unaryServer is a grpc.UnaryServerInterceptor.
+func (ib *defaultInterceptorBuilder) unaryServer(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+
+	start := time.Now()
+	ctx = ib.serverContext(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	ib.logOutcome(ctx, nil, err, time.Since(start))
+	return resp, err
+}
+
+// streamServer is a grpc.StreamServerInterceptor.
+func (ib *defaultInterceptorBuilder) streamServer(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	start := time.Now()
+	ctx := ib.serverContext(ss.Context(), info.FullMethod)
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	ib.logOutcome(ctx, nil, err, time.Since(start))
+	return err
+}
+
+// unaryClient is a grpc.UnaryClientInterceptor.
+func (ib *defaultInterceptorBuilder) unaryClient(ctx context.Context, method string, req, reply any,
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+	start := time.Now()
+	ctx = ib.clientContext(ctx, method)
+	var p peer.Peer
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+	ib.logOutcome(ctx, &p, err, time.Since(start))
+	return err
+}
+
+// streamClient is a grpc.StreamClientInterceptor.  The call outcome is logged when the stream ends.
+func (ib *defaultInterceptorBuilder) streamClient(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+
+	start := time.Now()
+	ctx = ib.clientContext(ctx, method)
+	p := new(peer.Peer)
+	cs, err := streamer(ctx, desc, cc, method, append(opts, grpc.Peer(p))...)
+	if err != nil {
+		ib.logOutcome(ctx, p, err, time.Since(start))
+		return nil, err
+	}
+	stream := &clientStream{ClientStream: cs, serverStreams: desc.ServerStreams, peer: p,
+		done: func(p *peer.Peer, err error) {
+			ib.logOutcome(ctx, p, err, time.Since(start))
+		}}
+	// A stream that is cancelled or abandoned before it is drained ends when its Context is done.  gRPC sets the peer
+	// concurrently when it ends the stream, so the peer address is not logged.
+	stream.stop = context.AfterFunc(ctx, func() {
+		stream.end(nil, status.FromContextError(ctx.Err()).Err())
+	})
+	return stream, nil
+}
+
+// serverContext returns the Context seeded with the method, peer address and incoming metadata.
+func (ib *defaultInterceptorBuilder) serverContext(ctx context.Context, method string) context.Context {
+	attrs := make([]slog.Attr, 0, 2+len(ib.metadataKeys))
+	attrs = appendAttr(attrs, ib.attrKeys.Method, slog.StringValue(method))
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = appendAttr(attrs, ib.attrKeys.Peer, slog.StringValue(p.Addr.String()))
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	attrs = ib.appendMetadata(attrs, md)
+	return slogx.ContextWithGroupAttrs(ctx, ib.group, attrs...)
+}
+
+// clientContext returns the Context seeded with the method and outgoing metadata.  The peer address is not known
+// until the call is made, so it is added to the logged call outcome only.
+func (ib *defaultInterceptorBuilder) clientContext(ctx context.Context, method string) context.Context {
+	attrs := make([]slog.Attr, 0, 1+len(ib.metadataKeys))
+	attrs = appendAttr(attrs, ib.attrKeys.Method, slog.StringValue(method))
+	md, _ := metadata.FromOutgoingContext(ctx)
+	attrs = ib.appendMetadata(attrs, md)
+	return slogx.ContextWithGroupAttrs(ctx, ib.group, attrs...)
+}
+
+// appendMetadata appends the allow-listed metadata as attrs.
+func (ib *defaultInterceptorBuilder) appendMetadata(attrs []slog.Attr, md metadata.MD) []slog.Attr {
+	for _, key := range ib.metadataKeys {
+		if values := md.Get(key); len(values) > 0 {
+			attrs = append(attrs, slog.String(key, strings.Join(values, ",")))
+		}
+	}
+	return attrs
+}
+
+// logOutcome logs the outcome of a finished call.
+func (ib *defaultInterceptorBuilder) logOutcome(ctx context.Context, p *peer.Peer, err error, duration time.Duration) {
+	logger := ib.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if ib.codeLevelFunc != nil {
+		level = ib.codeLevelFunc(code)
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 4)
+	if p != nil && p.Addr != nil {
+		attrs = appendAttr(attrs, ib.attrKeys.Peer, slog.StringValue(p.Addr.String()))
+	}
+	attrs = appendAttr(attrs, ib.attrKeys.Code, slog.StringValue(code.String()))
+	attrs = appendAttr(attrs, ib.attrKeys.Duration, slog.DurationValue(duration))
+	if err != nil {
+		attrs = appendAttr(attrs, ib.attrKeys.Error, slog.StringValue(err.Error()))
+	}
+	logger.LogAttrs(ctx, level, ib.message, attrs...)
+}
+
+// appendAttr appends an Attr with the provided key and value, unless the key is empty.
+func appendAttr(attrs []slog.Attr, key string, value slog.Value) []slog.Attr {
+	if key == "" {
+		return attrs
+	}
+	return append(attrs, slog.Attr{Key: key, Value: value})
+}
+
+// serverStream is a grpc.ServerStream that returns the seeded Context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
+}
+
+// clientStream is a grpc.ClientStream that calls done once when the stream ends.  The stream ends when RecvMsg
+// returns the final response, io.EOF or an error, when CloseSend fails or when the stream Context is done.
+type clientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	peer          *peer.Peer
+	once          sync.Once
+	done          func(p *peer.Peer, err error)
+	stop          func() bool
+}
+
+func (cs *clientStream) CloseSend() error {
+	err := cs.ClientStream.CloseSend()
+	if err != nil {
+		cs.finish(err)
+	}
+	return err
+}
+
+func (cs *clientStream) RecvMsg(m any) error {
+	err := cs.ClientStream.RecvMsg(m)
+	if err == nil {
+		// A stream without server streaming ends with the single response message
+		if !cs.serverStreams {
+			cs.finish(nil)
+		}
+	} else {
+		// io.EOF marks the successful end of the stream
+		if errors.Is(err, io.EOF) {
+			cs.finish(nil)
+		} else {
+			cs.finish(err)
+		}
+	}
+	return err
+}
+
+// finish ends the stream with the peer of the call.
+func (cs *clientStream) finish(err error) {
+	cs.end(cs.peer, err)
+}
+
+// end calls done with the provided peer the first time it is called.
+func (cs *clientStream) end(p *peer.Peer, err error) {
+	cs.once.Do(func() {
+		if cs.stop != nil {
+			cs.stop()
+		}
+		cs.done(p, err)
+	})
+}
@@ -0,0 +1,266 @@
+package grpcx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Evernorth/slogx-go/slogx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// HELPERS
+
+// syncBuffer is a bytes.Buffer that is safe to use from the server and client goroutines.
+type syncBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) entries(t *testing.T) []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var entries []map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(b.buffer.Bytes()))
+	for decoder.More() {
+		var entry map[string]any
+		require.NoError(t, decoder.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func newTestLogger(buffer *syncBuffer) *slog.Logger {
+	logger, _ := slogx.NewLoggerBuilder().
+		WithWriter(buffer).
+		WithFormat(slogx.FormatJSON).
+		WithContextHandler().
+		Build()
+	return logger
+}
+
+// startTestServer starts a health server on an in-process bufconn listener and returns a client connected to it.
+func startTestServer(t *testing.T, server Interceptors, client Interceptors) (healthpb.HealthClient, *health.Server) {
+	listener := bufconn.Listen(1024 * 1024)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(server.UnaryServer),
+		grpc.StreamInterceptor(server.StreamServer))
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(client.UnaryClient),
+		grpc.WithStreamInterceptor(client.StreamClient))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return healthpb.NewHealthClient(conn), healthServer
+}
+
+// TESTS
+
+func TestUnaryInterceptors(t *testing.T) {
+	serverBuffer := &syncBuffer{}
+	clientBuffer := &syncBuffer{}
+
+	client, _ := startTestServer(t,
+		NewInterceptorBuilder().
+			WithLogger(newTestLogger(serverBuffer)).
+			WithMetadataKeys("X-Tenant").
+			Build(),
+		NewInterceptorBuilder().
+			WithLogger(newTestLogger(clientBuffer)).
+			WithMetadataKeys("x-tenant").
+			Build())
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant", "t1", "x-secret", "s1")
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "orders"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	serverEntries := serverBuffer.entries(t)
+	require.Len(t, serverEntries, 1)
+	assert.Equal(t, "gRPC call", serverEntries[0]["msg"])
+	assert.Equal(t, "INFO", serverEntries[0]["level"])
+	assert.Equal(t, "/grpc.health.v1.Health/Check", serverEntries[0]["grpc_method"])
+	assert.Equal(t, "bufconn", serverEntries[0]["peer_addr"])
+	assert.Equal(t, "t1", serverEntries[0]["x-tenant"])
+	assert.NotContains(t, serverEntries[0], "x-secret")
+	assert.Equal(t, "OK", serverEntries[0]["grpc_code"])
+	assert.Contains(t, serverEntries[0], "duration")
+	assert.NotContains(t, serverEntries[0], "error")
+
+	clientEntries := clientBuffer.entries(t)
+	require.Len(t, clientEntries, 1)
+	assert.Equal(t, "/grpc.health.v1.Health/Check", clientEntries[0]["grpc_method"])
+	assert.Equal(t, "bufconn", clientEntries[0]["peer_addr"])
+	assert.Equal(t, "t1", clientEntries[0]["x-tenant"])
+	assert.Equal(t, "OK", clientEntries[0]["grpc_code"])
+}
+
+func TestUnaryInterceptors_Error(t *testing.T) {
+	serverBuffer := &syncBuffer{}
+	clientBuffer := &syncBuffer{}
+
+	client, _ := startTestServer(t,
+		NewInterceptorBuilder().WithLogger(newTestLogger(serverBuffer)).Build(),
+		NewInterceptorBuilder().WithLogger(newTestLogger(clientBuffer)).Build())
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	for _, entries := range [][]map[string]any{serverBuffer.entries(t), clientBuffer.entries(t)} {
+		require.Len(t, entries, 1)
+		assert.Equal(t, "WARN", entries[0]["level"])
+		assert.Equal(t, "NotFound", entries[0]["grpc_code"])
+		assert.Contains(t, entries[0]["error"], "unknown service")
+	}
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	serverBuffer := &syncBuffer{}
+	clientBuffer := &syncBuffer{}
+
+	serverDone := make(chan struct{})
+	server := NewInterceptorBuilder().WithLogger(newTestLogger(serverBuffer)).WithGroup("grpc").Build()
+	serverStream := server.StreamServer
+	server.StreamServer = func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		defer close(serverDone)
+		return serverStream(srv, ss, info, handler)
+	}
+
+	client, _ := startTestServer(t,
+		server,
+		NewInterceptorBuilder().WithLogger(newTestLogger(clientBuffer)).WithGroup("grpc").Build())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "orders"})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// Watch streams until the call is cancelled
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	<-serverDone
+
+	serverEntries := serverBuffer.entries(t)
+	require.Len(t, serverEntries, 1)
+	assert.Equal(t, map[string]any{"grpc_method": "/grpc.health.v1.Health/Watch", "peer_addr": "bufconn"},
+		serverEntries[0]["grpc"])
+	assert.Equal(t, "Canceled", serverEntries[0]["grpc_code"])
+
+	clientEntries := clientBuffer.entries(t)
+	require.Len(t, clientEntries, 1)
+	assert.Equal(t, map[string]any{"grpc_method": "/grpc.health.v1.Health/Watch"}, clientEntries[0]["grpc"])
+	assert.Equal(t, "Canceled", clientEntries[0]["grpc_code"])
+}
+
+func TestStreamClient_Abandoned(t *testing.T) {
+	clientBuffer := &syncBuffer{}
+	client, _ := startTestServer(t,
+		NewInterceptorBuilder().WithLogger(newTestLogger(&syncBuffer{})).Build(),
+		NewInterceptorBuilder().WithLogger(newTestLogger(clientBuffer)).Build())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "orders"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	// The stream is cancelled without being drained, and is logged once when its Context is done
+	cancel()
+	assert.Eventually(t, func() bool {
+		return len(clientBuffer.entries(t)) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	clientEntries := clientBuffer.entries(t)
+	require.Len(t, clientEntries, 1)
+	assert.Equal(t, "Canceled", clientEntries[0]["grpc_code"])
+}
+
+// failingClientStream is a grpc.ClientStream whose CloseSend fails.
+type failingClientStream struct {
+	grpc.ClientStream
+}
+
+func (failingClientStream) CloseSend() error {
+	return status.Error(codes.Unavailable, "connection lost")
+}
+
+func TestClientStream_CloseSendError(t *testing.T) {
+	var errs []error
+	cs := &clientStream{ClientStream: failingClientStream{}, done: func(_ *peer.Peer, err error) {
+		errs = append(errs, err)
+	}}
+
+	assert.Error(t, cs.CloseSend())
+	assert.Error(t, cs.CloseSend())
+	require.Len(t, errs, 1)
+	assert.Equal(t, codes.Unavailable, status.Code(errs[0]))
+}
+
+func TestDefaultCodeLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelInfo, DefaultCodeLevel(codes.OK))
+	assert.Equal(t, slog.LevelWarn, DefaultCodeLevel(codes.InvalidArgument))
+	assert.Equal(t, slog.LevelWarn, DefaultCodeLevel(codes.Unauthenticated))
+	assert.Equal(t, slog.LevelError, DefaultCodeLevel(codes.Internal))
+	assert.Equal(t, slog.LevelError, DefaultCodeLevel(codes.Unavailable))
+}
+
+func TestCodeLevelFuncBelowLoggerLevel(t *testing.T) {
+	serverBuffer := &syncBuffer{}
+	client, _ := startTestServer(t,
+		NewInterceptorBuilder().
+			WithLogger(newTestLogger(serverBuffer)).
+			WithCodeLevelFunc(func(code codes.Code) slog.Level { return slog.LevelDebug }).
+			Build(),
+		NewInterceptorBuilder().WithLogger(newTestLogger(&syncBuffer{})).Build())
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "orders"})
+	require.NoError(t, err)
+
+	assert.Empty(t, serverBuffer.entries(t))
+}
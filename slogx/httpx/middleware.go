@@ -0,0 +1,330 @@
+/*
+Package httpx provides net/http integrations for slogx.
+
+See documentation: https://pkg.go.dev/github.com/Evernorth/slogx-go
+*/
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Evernorth/slogx-go/slogx"
+)
+
+// DefaultRequestIDHeader is the default header used to read and return the request ID.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of a request ID read from a request header.
+const maxRequestIDLength = 128
+
+// AttrKeys are the keys of the attrs added by the middleware.  An empty key disables the attr.
+type AttrKeys struct {
+	Method     string
+	Path       string
+	RemoteAddr string
+	RequestID  string
+	Status     string
+	Duration   string
+	Bytes      string
+}
+
+// DefaultAttrKeys returns the default AttrKeys.
+func DefaultAttrKeys() AttrKeys {
+	return AttrKeys{
+		Method:     "method",
+		Path:       "path",
+		RemoteAddr: "remote_addr",
+		RequestID:  "request_id",
+		Status:     "status",
+		Duration:   "duration",
+		Bytes:      "bytes",
+	}
+}
+
+// StatusLevelFunc returns the level to log the access line at for the provided response status code.
+type StatusLevelFunc func(status int) slog.Level
+
+// DefaultStatusLevel logs 5xx responses at LevelError, 4xx responses at LevelWarn and all other responses at
+// LevelInfo.
+func DefaultStatusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// MiddlewareBuilder builds net/http middleware that seeds the request Context with request-scoped attrs using
+// slogx.ContextWithAttrs and logs an access line when the wrapped handler finishes.  Use the middleware with a logger
+// built with LoggerBuilder.WithContextHandler so that the attrs are added to every record logged with the request
+// Context.
+type MiddlewareBuilder interface {
+	WithLogger(logger *slog.Logger) MiddlewareBuilder
+	WithRequestIDHeader(header string) MiddlewareBuilder
+	WithRequestIDFunc(requestIDFunc func() string) MiddlewareBuilder
+	WithRemoteAddrHeader(header string) MiddlewareBuilder
+	WithAttrKeys(attrKeys AttrKeys) MiddlewareBuilder
+	WithGroup(group string) MiddlewareBuilder
+	WithStatusLevelFunc(statusLevelFunc StatusLevelFunc) MiddlewareBuilder
+	WithMessage(message string) MiddlewareBuilder
+	Build() func(http.Handler) http.Handler
+}
+
+type defaultMiddlewareBuilder struct {
+	logger           *slog.Logger
+	requestIDHeader  string
+	requestIDFunc    func() string
+	remoteAddrHeader string
+	attrKeys         AttrKeys
+	group            string
+	statusLevelFunc  StatusLevelFunc
+	message          string
+}
+
+// NewMiddlewareBuilder creates a new MiddlewareBuilder with default values.  The default values are:
+// logger=slog.Default(), requestIDHeader=DefaultRequestIDHeader, requestIDFunc=NewRequestID, remoteAddrHeader="",
+// attrKeys=DefaultAttrKeys(), group="", statusLevelFunc=DefaultStatusLevel and message="HTTP request".
+func NewMiddlewareBuilder() MiddlewareBuilder {
+	return &defaultMiddlewareBuilder{
+		logger:           nil,
+		requestIDHeader:  DefaultRequestIDHeader,
+		requestIDFunc:    NewRequestID,
+		remoteAddrHeader: "",
+		attrKeys:         DefaultAttrKeys(),
+		group:            "",
+		statusLevelFunc:  DefaultStatusLevel,
+		message:          "HTTP request",
+	}
+}
+
+// WithLogger sets the logger used to log the access line.  If not set, slog.Default() at the time of the request is
+// used.
+func (mb *defaultMiddlewareBuilder) WithLogger(logger *slog.Logger) MiddlewareBuilder {
+	mb.logger = logger
+	return mb
+}
+
+// WithRequestIDHeader sets the header the request ID is read from.  If the request does not have the header, or its
+// value is longer than 128 characters or has characters other than letters, digits and "-_.:", a new request ID is
+// generated.  The request ID is returned to the client in the same header.
+func (mb *defaultMiddlewareBuilder) WithRequestIDHeader(header string) MiddlewareBuilder {
+	mb.requestIDHeader = header
+	return mb
+}
+
+// WithRequestIDFunc sets the function used to generate a request ID when the request does not have one.
+func (mb *defaultMiddlewareBuilder) WithRequestIDFunc(requestIDFunc func() string) MiddlewareBuilder {
+	mb.requestIDFunc = requestIDFunc
+	return mb
+}
+
+// WithRemoteAddrHeader sets a header, such as X-Forwarded-For or X-Real-IP, that the remote address is read from.
+// The first address in the header is used.  If the request does not have the header, http.Request.RemoteAddr is used.
+func (mb *defaultMiddlewareBuilder) WithRemoteAddrHeader(header string) MiddlewareBuilder {
+	mb.remoteAddrHeader = header
+	return mb
+}
+
+// WithAttrKeys sets the keys of the attrs added by the middleware.
+func (mb *defaultMiddlewareBuilder) WithAttrKeys(attrKeys AttrKeys) MiddlewareBuilder {
+	mb.attrKeys = attrKeys
+	return mb
+}
+
+// WithGroup adds the request-scoped attrs to the Context under the named group using slogx.ContextWithGroupAttrs.
+func (mb *defaultMiddlewareBuilder) WithGroup(group string) MiddlewareBuilder {
+	mb.group = group
+	return mb
+}
+
+// WithStatusLevelFunc sets the function that selects the level of the access line from the response status code.
+func (mb *defaultMiddlewareBuilder) WithStatusLevelFunc(statusLevelFunc StatusLevelFunc) MiddlewareBuilder {
+	mb.statusLevelFunc = statusLevelFunc
+	return mb
+}
+
+// WithMessage sets the message of the access line.
+func (mb *defaultMiddlewareBuilder) WithMessage(message string) MiddlewareBuilder {
+	mb.message = message
+	return mb
+}
+
+// Build creates the middleware with the provided configuration.
+func (mb *defaultMiddlewareBuilder) Build() func(http.Handler) http.Handler {
+	m := *mb
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.serveHTTP(next, w, r)
+		})
+	}
+}
+
+// serveHTTP seeds the request Context, calls the next handler and logs the access line.
+func (mb *defaultMiddlewareBuilder) serveHTTP(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	// Read the request ID, or generate one, and return it to the client
+	requestID := ""
+	if mb.requestIDHeader != "" {
+		requestID = r.Header.Get(mb.requestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = ""
+		}
+	}
+	if requestID == "" && mb.requestIDFunc != nil {
+		requestID = mb.requestIDFunc()
+	}
+	if requestID != "" && mb.requestIDHeader != "" {
+		w.Header().Set(mb.requestIDHeader, requestID)
+	}
+
+	// Seed the request Context
+	attrs := make([]slog.Attr, 0, 4)
+	attrs = appendAttr(attrs, mb.attrKeys.Method, slog.StringValue(r.Method))
+	attrs = appendAttr(attrs, mb.attrKeys.Path, slog.StringValue(r.URL.Path))
+	attrs = appendAttr(attrs, mb.attrKeys.RemoteAddr, slog.StringValue(mb.remoteAddr(r)))
+	if requestID != "" {
+		attrs = appendAttr(attrs, mb.attrKeys.RequestID, slog.StringValue(requestID))
+	}
+	ctx := slogx.ContextWithGroupAttrs(r.Context(), mb.group, attrs...)
+
+	// Log the access line even if the handler panics, with status 500, and let the panic continue
+	rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+	panicked := true
+	defer func() {
+		if panicked {
+			rw.status = http.StatusInternalServerError
+		}
+		mb.logAccess(ctx, rw, time.Since(start))
+	}()
+	next.ServeHTTP(rw, r.WithContext(ctx))
+	panicked = false
+}
+
+// logAccess logs the access line for a finished request.
+func (mb *defaultMiddlewareBuilder) logAccess(ctx context.Context, rw *responseWriter, duration time.Duration) {
+	logger := mb.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	level := slog.LevelInfo
+	if mb.statusLevelFunc != nil {
+		level = mb.statusLevelFunc(rw.status)
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 3)
+	attrs = appendAttr(attrs, mb.attrKeys.Status, slog.IntValue(rw.status))
+	attrs = appendAttr(attrs, mb.attrKeys.Duration, slog.DurationValue(duration))
+	attrs = appendAttr(attrs, mb.attrKeys.Bytes, slog.Int64Value(rw.bytes))
+	logger.LogAttrs(ctx, level, mb.message, attrs...)
+}
+
+// remoteAddr returns the remote address of the request, from the remote address header if one is configured.
+func (mb *defaultMiddlewareBuilder) remoteAddr(r *http.Request) string {
+	if mb.remoteAddrHeader != "" {
+		if value := r.Header.Get(mb.remoteAddrHeader); value != "" {
+			addr, _, _ := strings.Cut(value, ",")
+			return strings.TrimSpace(addr)
+		}
+	}
+	return r.RemoteAddr
+}
+
+// isValidRequestID reports whether a request ID read from a request header is not empty, is at most 128 characters
+// long and has only letters, digits and "-_.:", so that it is safe to log and to return to the client.
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(requestID) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// appendAttr appends an Attr with the provided key and value, unless the key is empty.
+func appendAttr(attrs []slog.Attr, key string, value slog.Value) []slog.Attr {
+	if key == "" {
+		return attrs
+	}
+	return append(attrs, slog.Attr{Key: key, Value: value})
+}
+
+// NewRequestID returns a new random request ID of 32 hex characters.
+func NewRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// responseWriter is an http.ResponseWriter that records the status code and the number of bytes written.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	// Informational (1xx) responses may precede the final status code
+	if !rw.wroteHeader && status >= http.StatusOK {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the wrapped http.ResponseWriter does.
+func (rw *responseWriter) Flush() {
+	rw.wroteHeader = true
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the wrapped http.ResponseWriter does, so that WebSocket handlers can take over
+// the connection.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err == nil && !rw.wroteHeader {
+		rw.status = http.StatusSwitchingProtocols
+		rw.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter for use with http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
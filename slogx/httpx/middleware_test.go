@@ -0,0 +1,320 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Evernorth/slogx-go/slogx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// HELPERS
+
+func newTestLogger(buffer *bytes.Buffer) *slog.Logger {
+	logger, _ := slogx.NewLoggerBuilder().
+		WithWriter(buffer).
+		WithFormat(slogx.FormatJSON).
+		WithLevel(slog.LevelDebug).
+		WithContextHandler().
+		Build()
+	return logger
+}
+
+func parseLogLines(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	decoder := json.NewDecoder(buffer)
+	for decoder.More() {
+		var entry map[string]any
+		require.NoError(t, decoder.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TESTS
+
+func TestMiddleware(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger := newTestLogger(buffer)
+
+	middleware := NewMiddlewareBuilder().
+		WithLogger(logger).
+		WithRequestIDFunc(func() string { return "generated-id" }).
+		Build()
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "in handler")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, "hello")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?id=1", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "generated-id", rec.Header().Get(DefaultRequestIDHeader))
+
+	entries := parseLogLines(t, buffer)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "POST", entry["method"])
+		assert.Equal(t, "/orders", entry["path"])
+		assert.Equal(t, "192.0.2.1:1234", entry["remote_addr"])
+		assert.Equal(t, "generated-id", entry["request_id"])
+	}
+
+	assert.Equal(t, "in handler", entries[0]["msg"])
+
+	access := entries[1]
+	assert.Equal(t, "HTTP request", access["msg"])
+	assert.Equal(t, "INFO", access["level"])
+	assert.Equal(t, float64(http.StatusCreated), access["status"])
+	assert.Equal(t, float64(5), access["bytes"])
+	assert.Contains(t, access, "duration")
+}
+
+func TestMiddleware_RequestIDFromHeader(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	middleware := NewMiddlewareBuilder().
+		WithLogger(newTestLogger(buffer)).
+		WithRequestIDHeader("X-Correlation-ID").
+		Build()
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", "incoming-id")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, "incoming-id", rec.Header().Get("X-Correlation-ID"))
+	assert.Empty(t, rec.Header().Get(DefaultRequestIDHeader))
+
+	entries := parseLogLines(t, buffer)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "incoming-id", entries[0]["request_id"])
+	assert.Equal(t, float64(http.StatusOK), entries[0]["status"])
+}
+
+func TestMiddleware_GeneratedRequestID(t *testing.T) {
+	middleware := NewMiddlewareBuilder().
+		WithLogger(newTestLogger(bytes.NewBufferString(""))).
+		Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Len(t, rec.Header().Get(DefaultRequestIDHeader), 32)
+	assert.NotEqual(t, NewRequestID(), NewRequestID())
+}
+
+func TestMiddleware_RemoteAddrHeader(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	middleware := NewMiddlewareBuilder().
+		WithLogger(newTestLogger(buffer)).
+		WithRemoteAddrHeader("X-Forwarded-For").
+		Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := parseLogLines(t, buffer)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "203.0.113.7", entries[0]["remote_addr"])
+	assert.Equal(t, "192.0.2.1:1234", entries[1]["remote_addr"])
+}
+
+func TestMiddleware_AttrKeysAndGroup(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	attrKeys := DefaultAttrKeys()
+	attrKeys.Path = "route"
+	attrKeys.RemoteAddr = ""
+	attrKeys.Bytes = ""
+
+	middleware := NewMiddlewareBuilder().
+		WithLogger(newTestLogger(buffer)).
+		WithAttrKeys(attrKeys).
+		WithGroup("http").
+		WithMessage("access").
+		Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(DefaultRequestIDHeader, "r1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := parseLogLines(t, buffer)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "access", entries[0]["msg"])
+	assert.Equal(t, map[string]any{"method": "GET", "route": "/orders", "request_id": "r1"}, entries[0]["http"])
+	assert.NotContains(t, entries[0], "bytes")
+	assert.Contains(t, entries[0], "status")
+}
+
+func TestMiddleware_StatusLevel(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected string
+	}{
+		{status: http.StatusOK, expected: "INFO"},
+		{status: http.StatusFound, expected: "INFO"},
+		{status: http.StatusNotFound, expected: "WARN"},
+		{status: http.StatusServiceUnavailable, expected: "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			buffer := bytes.NewBufferString("")
+			middleware := NewMiddlewareBuilder().WithLogger(newTestLogger(buffer)).Build()
+			handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			entries := parseLogLines(t, buffer)
+			require.Len(t, entries, 1)
+			assert.Equal(t, tt.expected, entries[0]["level"])
+		})
+	}
+}
+
+func TestMiddleware_CustomStatusLevelBelowLoggerLevel(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	middleware := NewMiddlewareBuilder().
+		WithLogger(newTestLogger(buffer)).
+		WithStatusLevelFunc(func(status int) slog.Level { return slog.LevelDebug - 4 }).
+		Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Empty(t, buffer.String())
+}
+
+func TestMiddleware_InformationalStatus(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	middleware := NewMiddlewareBuilder().WithLogger(newTestLogger(buffer)).Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	entries := parseLogLines(t, buffer)
+	require.Len(t, entries, 1)
+	assert.Equal(t, float64(http.StatusAccepted), entries[0]["status"])
+}
+
+func TestMiddleware_ResponseController(t *testing.T) {
+	middleware := NewMiddlewareBuilder().WithLogger(newTestLogger(bytes.NewBufferString(""))).Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "partial")
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.True(t, rec.Flushed)
+}
+
+func TestMiddleware_InvalidRequestID(t *testing.T) {
+	middleware := NewMiddlewareBuilder().
+		WithLogger(newTestLogger(bytes.NewBufferString(""))).
+		WithRequestIDFunc(func() string { return "generated" }).
+		Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, requestID := range []string{"bad id\r\nX-Injected: 1", "<script>", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(DefaultRequestIDHeader, requestID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, "generated", rec.Header().Get(DefaultRequestIDHeader))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(DefaultRequestIDHeader, "trace:0af7651916cd43dd_8448eb211c80319c."+strings.Repeat("a", 88))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, req.Header.Get(DefaultRequestIDHeader), rec.Header().Get(DefaultRequestIDHeader))
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	middleware := NewMiddlewareBuilder().WithLogger(newTestLogger(buffer)).Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+
+	// The panic continues after the access line is logged
+	assert.PanicsWithValue(t, "handler failed", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+
+	entries := parseLogLines(t, buffer)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ERROR", entries[0]["level"])
+	assert.Equal(t, float64(http.StatusInternalServerError), entries[0]["status"])
+	assert.Equal(t, "/panic", entries[0]["path"])
+}
+
+// hijackRecorder is an httptest.ResponseRecorder that implements http.Hijacker.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	server, client := net.Pipe()
+	_ = client.Close()
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+func TestMiddleware_Hijack(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	middleware := NewMiddlewareBuilder().WithLogger(newTestLogger(buffer)).Build()
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		require.NoError(t, err)
+		_ = conn.Close()
+	}))
+
+	rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	assert.True(t, rec.hijacked)
+
+	entries := parseLogLines(t, buffer)
+	require.Len(t, entries, 1)
+	assert.Equal(t, float64(http.StatusSwitchingProtocols), entries[0]["status"])
+
+	// A http.ResponseWriter that cannot be hijacked reports http.ErrNotSupported
+	handler = middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		require.True(t, ok)
+		_, _, err := hijacker.Hijack()
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws", nil))
+}
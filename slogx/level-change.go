@@ -0,0 +1,110 @@
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// LevelSource identifies what changed a managed level.
+type LevelSource string
+
+// The sources of managed level changes.
+const (
+	// LevelSourceEnv is an update from an environment variable enrolled with ManageLevelFromEnv.
+	LevelSourceEnv LevelSource = "env"
+
+	// LevelSourceFunc is an update from a LevelFunc enrolled with ManageLevelFromFunc.
+	LevelSourceFunc LevelSource = "func"
+
+	// LevelSourceSet is a change made with ExtendedLevelManager.SetLevel.
+	LevelSourceSet LevelSource = "set"
+
+	// LevelSourceOverride is a change made with ExtendedLevelManager.OverrideLevel.
+	LevelSourceOverride LevelSource = "override"
+
+	// LevelSourceHTTP is a change made with the handler returned by NewLevelHandler.
+	LevelSourceHTTP LevelSource = "http"
+
+	// LevelSourceSignal is a change made by a signal handled by ExtendedLevelManager.StartSignals.
+	LevelSourceSignal LevelSource = "signal"
+)
+
+// levelChangeSubscriber holds a function registered with OnLevelChange.  Subscribers are compared by pointer, so that
+// each registration can be unsubscribed.
+type levelChangeSubscriber struct {
+	f func(key string, old, new slog.Level)
+}
+
+// OnLevelChange registers a function that is called each time the level of a managed slog.LevelVar changes, with the
+// key the slog.LevelVar is enrolled with and its old and new levels.  Functions are called synchronously in the order
+// they were registered, after the change is made, and may call the LevelManager.  Call the returned function to
+// unsubscribe.
+func (lm *defaultLevelManager) OnLevelChange(f func(key string, old, new slog.Level)) (unsubscribe func()) {
+	subscriber := &levelChangeSubscriber{f: f}
+
+	lm.subscribersMu.Lock()
+	lm.subscribers = append(lm.subscribers, subscriber)
+	lm.subscribersMu.Unlock()
+
+	return func() {
+		lm.subscribersMu.Lock()
+		defer lm.subscribersMu.Unlock()
+		// Copy the subscribers, because notifyLevelChanges may be iterating over them
+		lm.subscribers = slices.DeleteFunc(slices.Clone(lm.subscribers), func(s *levelChangeSubscriber) bool {
+			return s == subscriber
+		})
+	}
+}
+
+// SetAuditLogger sets a logger that logs a line each time the level of a managed slog.LevelVar changes, with the key,
+// the old and new levels and the LevelSource of the change.  If logger is nil, audit logging is disabled.
+func (lm *defaultLevelManager) SetAuditLogger(logger *slog.Logger) {
+	lm.subscribersMu.Lock()
+	defer lm.subscribersMu.Unlock()
+	lm.auditLogger = logger
+}
+
+// levelChange is a change of the level of a managed slog.LevelVar.  Changes are collected while the level locks are
+// held, and notified with notifyLevelChanges after they are released, so that subscribers can call the LevelManager.
+type levelChange struct {
+	key      string
+	old, new slog.Level
+	source   LevelSource
+}
+
+// setLevelVar sets the level of a managed slog.LevelVar, and appends the change to changes if the level changed.  If a
+// LoggerRegistry sets the level of the slog.LevelVar, the level is applied when the LoggerRegistry no longer sets it.
+func setLevelVar(levelVar *slog.LevelVar, key string, level slog.Level, source LevelSource, changes *[]levelChange) {
+	old, changed := setUnpinnedLevel(levelVar, level)
+	if !changed {
+		return
+	}
+	*changes = append(*changes, levelChange{key: key, old: old, new: level, source: source})
+}
+
+// notifyLevelChanges notifies the subscribers and the audit logger of the level changes.  It must not be called while
+// overridesMu is held.
+func (lm *defaultLevelManager) notifyLevelChanges(changes *[]levelChange) {
+	if len(*changes) == 0 {
+		return
+	}
+
+	lm.subscribersMu.RLock()
+	subscribers := lm.subscribers
+	auditLogger := lm.auditLogger
+	lm.subscribersMu.RUnlock()
+
+	for _, change := range *changes {
+		if auditLogger != nil {
+			auditLogger.LogAttrs(context.Background(), slog.LevelInfo, "Level changed.",
+				slog.String("key", change.key),
+				slog.String("old_level", LevelName(change.old)),
+				slog.String("new_level", LevelName(change.new)),
+				slog.String("source", string(change.source)))
+		}
+		for _, subscriber := range subscribers {
+			subscriber.f(change.key, change.old, change.new)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedChange is a change recorded by a test subscriber.
+type recordedChange struct {
+	key      string
+	old, new slog.Level
+}
+
+func TestLevelManager_OnLevelChange(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar := &slog.LevelVar{}
+	key := "LEVEL_CHANGE_LEVEL"
+	require.NoError(t, levelManager.ManageLevelFromEnv(levelVar, key))
+
+	var changes1, changes2 []recordedChange
+	unsubscribe1 := levelManager.OnLevelChange(func(key string, old, new slog.Level) {
+		changes1 = append(changes1, recordedChange{key, old, new})
+	})
+	levelManager.OnLevelChange(func(key string, old, new slog.Level) {
+		changes2 = append(changes2, recordedChange{key, old, new})
+	})
+
+	t.Setenv(key, "DEBUG")
+	levelManager.UpdateLevels()
+
+	// Unchanged levels are not notified
+	levelManager.UpdateLevels()
+	require.NoError(t, levelManager.SetLevel(key, slog.LevelDebug))
+
+	unsubscribe1()
+	require.NoError(t, levelManager.SetLevel(key, slog.LevelError))
+
+	assert.Equal(t, []recordedChange{
+		{key: key, old: slog.LevelInfo, new: slog.LevelDebug},
+	}, changes1)
+	assert.Equal(t, []recordedChange{
+		{key: key, old: slog.LevelInfo, new: slog.LevelDebug},
+		{key: key, old: slog.LevelDebug, new: slog.LevelError},
+	}, changes2)
+}
+
+// auditLines returns the audit log lines in a buffer, without their time and level.
+func auditLines(t *testing.T, buffer *bytes.Buffer) []map[string]string {
+	t.Helper()
+	var lines []map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var fields map[string]string
+		require.NoError(t, json.Unmarshal([]byte(line), &fields))
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+func TestLevelManager_SetAuditLogger(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	envLevelVar := &slog.LevelVar{}
+	envKey := "AUDITED_ENV_LEVEL"
+	require.NoError(t, levelManager.ManageLevelFromEnv(envLevelVar, envKey))
+	funcLevelVar := &slog.LevelVar{}
+	funcKey := "AUDITED_FUNC_LEVEL"
+	require.NoError(t, levelManager.ManageLevelFromFunc(funcLevelVar, funcKey, func(key string) string {
+		return "WARN"
+	}))
+
+	buffer := &bytes.Buffer{}
+	levelManager.SetAuditLogger(slog.New(newTestJSONHandler(buffer)))
+
+	t.Setenv(envKey, "TRACE")
+	levelManager.UpdateLevels()
+	require.NoError(t, levelManager.SetLevel(envKey, slog.LevelError))
+	require.NoError(t, levelManager.OverrideLevel(funcKey, slog.LevelDebug, time.Hour))
+	require.NoError(t, levelManager.CancelOverride(funcKey))
+
+	response := serveLevelRequest(NewLevelHandler(levelManager), http.MethodPut, `{"key":"`+envKey+`","level":"INFO"}`)
+	require.Equal(t, http.StatusOK, response.Code)
+
+	levelManager.stepLevels(true)
+
+	lines := auditLines(t, buffer)
+	// The order of UpdateLevels is not deterministic
+	assert.ElementsMatch(t, []map[string]string{
+		{"msg": "Level changed.", "key": envKey, "old_level": "INFO", "new_level": "TRACE", "source": "env"},
+		{"msg": "Level changed.", "key": funcKey, "old_level": "INFO", "new_level": "WARN", "source": "func"},
+	}, lines[:2])
+	assert.Equal(t, []map[string]string{
+		{"msg": "Level changed.", "key": envKey, "old_level": "TRACE", "new_level": "ERROR", "source": "set"},
+		{"msg": "Level changed.", "key": funcKey, "old_level": "WARN", "new_level": "DEBUG", "source": "override"},
+		{"msg": "Level changed.", "key": funcKey, "old_level": "DEBUG", "new_level": "WARN", "source": "func"},
+		{"msg": "Level changed.", "key": envKey, "old_level": "ERROR", "new_level": "INFO", "source": "http"},
+	}, lines[2:6])
+	assert.ElementsMatch(t, []map[string]string{
+		{"msg": "Level changed.", "key": envKey, "old_level": "INFO", "new_level": "DEBUG", "source": "signal"},
+		{"msg": "Level changed.", "key": funcKey, "old_level": "WARN", "new_level": "NOTICE", "source": "signal"},
+	}, lines[6:])
+
+	// A nil audit logger disables audit logging
+	buffer.Reset()
+	levelManager.SetAuditLogger(nil)
+	require.NoError(t, levelManager.SetLevel(envKey, slog.LevelWarn))
+	assert.Empty(t, buffer.String())
+}
+
+func TestLevelManager_OnLevelChange_CallsLevelManager(t *testing.T) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	levelVar1 := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar1, "LEVEL_CHANGE_LEVEL_1", func(key string) string {
+		return "WARN"
+	}))
+	levelVar2 := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar2, "LEVEL_CHANGE_LEVEL_2", func(key string) string {
+		return "WARN"
+	}))
+
+	// A subscriber that overrides the second key when the first key changes, while the locks are not held
+	var overrides [][]LevelOverride
+	levelManager.OnLevelChange(func(key string, old, new slog.Level) {
+		overrides = append(overrides, levelManager.ActiveOverrides())
+		if key == "LEVEL_CHANGE_LEVEL_1" {
+			assert.NoError(t, levelManager.OverrideLevel("LEVEL_CHANGE_LEVEL_2", new, time.Minute))
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		levelManager.UpdateLevels()
+		require.NoError(t, levelManager.OverrideLevel("LEVEL_CHANGE_LEVEL_1", slog.LevelDebug, time.Minute))
+		clock.fireTimers()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "subscriber deadlocked")
+	}
+
+	assert.Equal(t, slog.LevelWarn, levelVar1.Level())
+	assert.Equal(t, slog.LevelWarn, levelVar2.Level())
+	assert.NotEmpty(t, overrides)
+}
@@ -0,0 +1,200 @@
+package slogx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxLevelRequestBytes limits the size of a level change request body.
+const maxLevelRequestBytes = 1 << 16
+
+// levelRequest is the body of a PUT or POST request to a level handler.
+type levelRequest struct {
+	Key   string `json:"key"`
+	Level string `json:"level"`
+	TTL   string `json:"ttl,omitempty"`
+}
+
+// levelResponse is the JSON representation of the level of a key.
+type levelResponse struct {
+	Key     string     `json:"key"`
+	Level   string     `json:"level"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// levelsResponse is the body of the response to a GET request to a level handler.
+type levelsResponse struct {
+	Levels []levelResponse `json:"levels"`
+}
+
+// errorResponse is the body of an error response from a level handler.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// sourcedLevelManager is implemented by LevelManagers that record the source of level changes.
+type sourcedLevelManager interface {
+	replaceLevel(key string, level slog.Level, source LevelSource) error
+	overrideLevel(key string, level slog.Level, duration time.Duration, source LevelSource) error
+}
+
+// levelHandler is the http.Handler returned by NewLevelHandler.
+type levelHandler struct {
+	levelManager ExtendedLevelManager
+}
+
+// NewLevelHandler returns an http.Handler for viewing and changing the levels managed by a LevelManager at runtime.
+// If levelManager is nil, GetExtendedLevelManager() is used.  Mount it on an admin mux, e.g.
+// mux.Handle("/admin/levels", slogx.NewLevelHandler(nil)).
+//
+// A GET request returns the level of every enrolled key:
+//
+//	{"levels":[{"key":"LOGGER1_LOG_LEVEL","level":"INFO"}]}
+//
+// A PUT or POST request sets the level of a key, and returns its new level.  The level name is parsed with
+// GetLevelByName.  If a TTL is provided, the level is set with ExtendedLevelManager.OverrideLevel, so it is reset from
+// the LevelFunc of the key when the TTL expires:
+//
+//	{"key":"LOGGER1_LOG_LEVEL","level":"DEBUG","ttl":"15m"}
+//
+// Errors are returned as {"error":"..."} with status 400 for an invalid request, and 404 for a key that is not
+// enrolled.
+func NewLevelHandler(levelManager ExtendedLevelManager) http.Handler {
+	if levelManager == nil {
+		levelManager = GetExtendedLevelManager()
+	}
+	return &levelHandler{
+		levelManager: levelManager,
+	}
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.getLevels(w)
+	case http.MethodPut, http.MethodPost:
+		h.putLevel(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+	}
+}
+
+// getLevels writes the level of every enrolled key, sorted by key.
+func (h *levelHandler) getLevels(w http.ResponseWriter) {
+	levels := h.levelManager.Levels()
+
+	response := levelsResponse{
+		Levels: make([]levelResponse, 0, len(levels)),
+	}
+	expiries := h.expiries()
+	for key, level := range levels {
+		response.Levels = append(response.Levels, newLevelResponse(key, level, expiries))
+	}
+	slices.SortFunc(response.Levels, func(a, b levelResponse) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	writeLevelJSON(w, http.StatusOK, response)
+}
+
+// putLevel sets the level of a key from the request body.
+func (h *levelHandler) putLevel(w http.ResponseWriter, r *http.Request) {
+	var request levelRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLevelRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeLevelError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if request.Key == "" {
+		writeLevelError(w, http.StatusBadRequest, errors.New("key is required"))
+		return
+	}
+	level, err := GetLevelByName(request.Level)
+	if err != nil {
+		writeLevelError(w, http.StatusBadRequest, err)
+		return
+	}
+	var ttl time.Duration
+	if request.TTL != "" {
+		ttl, err = time.ParseDuration(request.TTL)
+		if err != nil || ttl <= 0 {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("invalid ttl: %s", request.TTL))
+			return
+		}
+	}
+
+	if ttl > 0 {
+		err = h.overrideLevel(request.Key, *level, ttl)
+	} else {
+		err = h.setLevel(request.Key, *level)
+	}
+	if err != nil {
+		writeLevelError(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeLevelJSON(w, http.StatusOK, newLevelResponse(request.Key, *level, h.expiries()))
+}
+
+// setLevel ends any active override of a key and sets its level, recording LevelSourceHTTP as the source if the
+// LevelManager supports it.
+func (h *levelHandler) setLevel(key string, level slog.Level) error {
+	if lm, ok := h.levelManager.(sourcedLevelManager); ok {
+		return lm.replaceLevel(key, level, LevelSourceHTTP)
+	}
+	if _, ok := h.expiries()[key]; ok {
+		_ = h.levelManager.CancelOverride(key)
+	}
+	return h.levelManager.SetLevel(key, level)
+}
+
+// overrideLevel overrides the level of a key, recording LevelSourceHTTP as the source if the LevelManager supports
+// it.
+func (h *levelHandler) overrideLevel(key string, level slog.Level, ttl time.Duration) error {
+	if lm, ok := h.levelManager.(sourcedLevelManager); ok {
+		return lm.overrideLevel(key, level, ttl, LevelSourceHTTP)
+	}
+	return h.levelManager.OverrideLevel(key, level, ttl)
+}
+
+// expiries returns when the active overrides of each key expire.
+func (h *levelHandler) expiries() map[string]time.Time {
+	expiries := make(map[string]time.Time)
+	for _, override := range h.levelManager.ActiveOverrides() {
+		expiries[override.Key] = override.Expires
+	}
+	return expiries
+}
+
+// newLevelResponse returns the levelResponse of a key, including when its level expires.
+func newLevelResponse(key string, level slog.Level, expiries map[string]time.Time) levelResponse {
+	response := levelResponse{
+		Key:   key,
+		Level: LevelName(level),
+	}
+	if expires, ok := expiries[key]; ok {
+		response.Expires = &expires
+	}
+	return response
+}
+
+// writeLevelJSON writes a JSON response.
+func writeLevelJSON(w http.ResponseWriter, status int, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// writeLevelError writes a JSON error response.
+func writeLevelError(w http.ResponseWriter, status int, err error) {
+	writeLevelJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,181 @@
+package slogx
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveLevelRequest sends a request to a level handler and returns the recorded response.
+func serveLevelRequest(handler http.Handler, method string, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, "/admin/levels", strings.NewReader(body)))
+	return recorder
+}
+
+func newLevelHandlerTestManager(t *testing.T) (*defaultLevelManager, *fakeClock, *slog.LevelVar, *slog.LevelVar) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	levelVar1 := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar1, "LOGGER1_LOG_LEVEL", func(key string) string {
+		return "INFO"
+	}))
+	levelVar2 := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar2, "LOGGER2_LOG_LEVEL", func(key string) string {
+		return "WARN"
+	}))
+	levelManager.UpdateLevels()
+	return levelManager, clock, levelVar1, levelVar2
+}
+
+func TestLevelHandler_Get(t *testing.T) {
+	levelManager, _, _, _ := newLevelHandlerTestManager(t)
+	handler := NewLevelHandler(levelManager)
+
+	response := serveLevelRequest(handler, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+	assert.JSONEq(t,
+		`{"levels":[{"key":"LOGGER1_LOG_LEVEL","level":"INFO"},{"key":"LOGGER2_LOG_LEVEL","level":"WARN"}]}`,
+		response.Body.String())
+}
+
+func TestLevelHandler_Put(t *testing.T) {
+	levelManager, _, levelVar1, levelVar2 := newLevelHandlerTestManager(t)
+	handler := NewLevelHandler(levelManager)
+
+	response := serveLevelRequest(handler, http.MethodPut, `{"key":"LOGGER1_LOG_LEVEL","level":"trace"}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"key":"LOGGER1_LOG_LEVEL","level":"TRACE"}`, response.Body.String())
+	assert.Equal(t, LevelTrace, levelVar1.Level())
+	assert.Equal(t, slog.LevelWarn, levelVar2.Level())
+
+	response = serveLevelRequest(handler, http.MethodPost, `{"key":"LOGGER2_LOG_LEVEL","level":"DEBUG+2"}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, slog.LevelDebug+2, levelVar2.Level())
+}
+
+func TestLevelHandler_TTL(t *testing.T) {
+	levelManager, clock, levelVar1, _ := newLevelHandlerTestManager(t)
+	handler := NewLevelHandler(levelManager)
+
+	response := serveLevelRequest(handler, http.MethodPut, `{"key":"LOGGER1_LOG_LEVEL","level":"DEBUG","ttl":"50ms"}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, slog.LevelDebug, levelVar1.Level())
+
+	var level levelResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &level))
+	require.NotNil(t, level.Expires)
+	assert.Equal(t, clock.Now().Add(50*time.Millisecond), *level.Expires)
+
+	response = serveLevelRequest(handler, http.MethodGet, "")
+	assert.Contains(t, response.Body.String(), `"expires"`)
+
+	// The level is reset from the LevelFunc when the TTL expires
+	assert.Equal(t, 1, clock.fireTimers())
+	assert.Equal(t, slog.LevelInfo, levelVar1.Level())
+	response = serveLevelRequest(handler, http.MethodGet, "")
+	assert.NotContains(t, response.Body.String(), `"expires"`)
+}
+
+func TestLevelHandler_TTLReplaced(t *testing.T) {
+	levelManager, clock, levelVar1, _ := newLevelHandlerTestManager(t)
+	handler := NewLevelHandler(levelManager)
+
+	response := serveLevelRequest(handler, http.MethodPut, `{"key":"LOGGER1_LOG_LEVEL","level":"DEBUG","ttl":"20ms"}`)
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var levels []slog.Level
+	levelManager.OnLevelChange(func(key string, old, new slog.Level) {
+		levels = append(levels, new)
+	})
+
+	// Setting the level again without a TTL cancels the reset, without resetting the level from the LevelFunc first
+	response = serveLevelRequest(handler, http.MethodPut, `{"key":"LOGGER1_LOG_LEVEL","level":"ERROR"}`)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, []slog.Level{slog.LevelError}, levels)
+	assert.Empty(t, levelManager.ActiveOverrides())
+	assert.Equal(t, 0, clock.fireTimers())
+	assert.Equal(t, slog.LevelError, levelVar1.Level())
+}
+
+func TestLevelHandler_Errors(t *testing.T) {
+	levelManager, _, _, _ := newLevelHandlerTestManager(t)
+	handler := NewLevelHandler(levelManager)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		error  string
+	}{
+		{
+			name:   "invalid json",
+			method: http.MethodPut,
+			body:   `{"key":`,
+			status: http.StatusBadRequest,
+			error:  "invalid request body",
+		},
+		{
+			name:   "unknown field",
+			method: http.MethodPut,
+			body:   `{"key":"LOGGER1_LOG_LEVEL","level":"DEBUG","lvl":"DEBUG"}`,
+			status: http.StatusBadRequest,
+			error:  "invalid request body",
+		},
+		{
+			name:   "missing key",
+			method: http.MethodPut,
+			body:   `{"level":"DEBUG"}`,
+			status: http.StatusBadRequest,
+			error:  "key is required",
+		},
+		{
+			name:   "invalid level",
+			method: http.MethodPut,
+			body:   `{"key":"LOGGER1_LOG_LEVEL","level":"LOUD"}`,
+			status: http.StatusBadRequest,
+			error:  "invalid level name: LOUD",
+		},
+		{
+			name:   "invalid ttl",
+			method: http.MethodPut,
+			body:   `{"key":"LOGGER1_LOG_LEVEL","level":"DEBUG","ttl":"-1m"}`,
+			status: http.StatusBadRequest,
+			error:  "invalid ttl: -1m",
+		},
+		{
+			name:   "unknown key",
+			method: http.MethodPut,
+			body:   `{"key":"UNKNOWN_LOG_LEVEL","level":"DEBUG"}`,
+			status: http.StatusNotFound,
+			error:  "no level is managed with key UNKNOWN_LOG_LEVEL",
+		},
+		{
+			name:   "method",
+			method: http.MethodDelete,
+			status: http.StatusMethodNotAllowed,
+			error:  "method DELETE is not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := serveLevelRequest(handler, tt.method, tt.body)
+			assert.Equal(t, tt.status, response.Code)
+
+			var body errorResponse
+			require.NoError(t, json.Unmarshal(response.Body.Bytes(), &body))
+			assert.Contains(t, body.Error, tt.error)
+		})
+	}
+}
@@ -1,10 +1,17 @@
 package slogx
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"time"
+	"weak"
 )
 
 // LevelFunc is a function that returns the name of a level given a key.
@@ -19,30 +26,97 @@ type LevelManager interface {
 	UpdateLevels()
 }
 
-// defaultLevelManager is the default implementation of LevelManager.
+// ExtendedLevelManager is a LevelManager that can also change levels at runtime.  It is a separate interface so that
+// implementations of LevelManager outside slogx are not broken.  Call ManageLevelWeakly to enroll a slog.LevelVar until
+// it is garbage collected, Unmanage to remove an enrollment, and Managed to list the enrolled slog.LevelVar objects.
+// Call StartPolling to call UpdateLevels periodically, and StartSignals to change levels when the process receives a
+// signal, until Stop is called.  Call SetLevel to change the level of the slog.LevelVar objects enrolled with a key,
+// and ResetLevel to restore it from their LevelFunc.  Call OverrideLevel to change the level of a key for a limited
+// time.  Call OnLevelChange or SetAuditLogger to be notified when a level changes.
+type ExtendedLevelManager interface {
+	LevelManager
+	ManageLevelWeakly(levelVar *slog.LevelVar, key string, levelFunc LevelFunc) error
+	Unmanage(levelVar *slog.LevelVar) bool
+	Managed() []ManagedLevel
+	Levels() map[string]slog.Level
+	SetLevel(key string, level slog.Level) error
+	ResetLevel(key string) error
+	OverrideLevel(key string, level slog.Level, duration time.Duration) error
+	ActiveOverrides() []LevelOverride
+	CancelOverride(key string) error
+	StartPolling(ctx context.Context, interval time.Duration) error
+	StartSignals(ctx context.Context, opts *LevelSignalOptions) error
+	Stop()
+	OnLevelChange(f func(key string, old, new slog.Level)) (unsubscribe func())
+	SetAuditLogger(logger *slog.Logger)
+}
+
+// defaultLevelManager is the default implementation of ExtendedLevelManager.  The keys of levelVarMap are
+// *slog.LevelVar, or weak.Pointer[slog.LevelVar] for slog.LevelVar objects enrolled with ManageLevelWeakly.
 type defaultLevelManager struct {
 	levelVarMap *sync.Map
+	clock       clock
+
+	mu            sync.Mutex
+	cancelPolling context.CancelFunc
+	pollingDone   chan struct{}
+	cancelSignals context.CancelFunc
+	signalsDone   chan struct{}
+
+	// overridesMu guards overrides, and is held while levels are updated so that overridden levels are not replaced
+	overridesMu sync.Mutex
+	overrides   map[string]*levelOverride
+
+	subscribersMu sync.RWMutex
+	subscribers   []*levelChangeSubscriber
+	auditLogger   *slog.Logger
 }
 
 // defaultLevelManagerInstance is the singleton instance of defaultLevelManager.
-var defaultLevelManagerInstance = &defaultLevelManager{
-	levelVarMap: new(sync.Map),
-}
+var defaultLevelManagerInstance = newLevelManager()
 
 // GetLevelManager returns the singleton instance of LevelManager.
 func GetLevelManager() LevelManager {
 	return defaultLevelManagerInstance
 }
 
+// GetExtendedLevelManager returns the singleton instance of LevelManager as an ExtendedLevelManager.  It is the same
+// instance that GetLevelManager returns.
+func GetExtendedLevelManager() ExtendedLevelManager {
+	return defaultLevelManagerInstance
+}
+
+// NewLevelManager creates a new ExtendedLevelManager that is independent of the singleton returned by
+// GetLevelManager.  Use it to give a library or a test its own level control.
+func NewLevelManager() ExtendedLevelManager {
+	return newLevelManager()
+}
+
+// newLevelManager creates a new defaultLevelManager.
+func newLevelManager() *defaultLevelManager {
+	return &defaultLevelManager{
+		levelVarMap: new(sync.Map),
+		clock:       realClock{},
+	}
+}
+
+// ManagedLevel describes a slog.LevelVar enrolled in a LevelManager.
+type ManagedLevel struct {
+	Key    string
+	Level  slog.Level
+	Source LevelSource
+}
+
 type levelFuncHolder struct {
 	levelKey  string
 	levelFunc LevelFunc
+	source    LevelSource
 }
 
 // ManageLevelFromEnv associates a slog.LevelVar with an environment variable key.  The level of the slog.LevelVar will be
 // updated when UpdateLevels is called.
 func (lm *defaultLevelManager) ManageLevelFromEnv(defaultLevelVar *slog.LevelVar, key string) error {
-	err := lm.ManageLevelFromFunc(defaultLevelVar, key, getEnvLevelFunc())
+	err := lm.manageLevel(defaultLevelVar, key, getEnvLevelFunc(), LevelSourceEnv, false)
 	if err != nil {
 		return err
 	}
@@ -53,6 +127,25 @@ func (lm *defaultLevelManager) ManageLevelFromEnv(defaultLevelVar *slog.LevelVar
 // updated when UpdateLevels is called.
 // A LevelFunc is useful for getting a level name using alternate sources, such as koanf, viper, etc.
 func (lm *defaultLevelManager) ManageLevelFromFunc(defaultLevelVar *slog.LevelVar, key string, levelFunc LevelFunc) error {
+	return lm.manageLevel(defaultLevelVar, key, levelFunc, LevelSourceFunc, false)
+}
+
+// ManageLevelWeakly associates a slog.LevelVar with a key and a LevelFunc, like ManageLevelFromFunc, but holds a weak
+// reference to the slog.LevelVar, so that the enrollment is removed when the slog.LevelVar is garbage collected.  If
+// levelFunc is nil, the level is read from the environment variable key, like ManageLevelFromEnv.  Use it for
+// short-lived loggers, such as per-tenant or per-job loggers.
+func (lm *defaultLevelManager) ManageLevelWeakly(defaultLevelVar *slog.LevelVar, key string,
+	levelFunc LevelFunc) error {
+	if levelFunc == nil {
+		return lm.manageLevel(defaultLevelVar, key, getEnvLevelFunc(), LevelSourceEnv, true)
+	}
+	return lm.manageLevel(defaultLevelVar, key, levelFunc, LevelSourceFunc, true)
+}
+
+// manageLevel associates a slog.LevelVar with a key and a LevelFunc, recording the source of the LevelFunc, and holds
+// the slog.LevelVar weakly if weakly is true.  Enrolling a slog.LevelVar again replaces its enrollment.
+func (lm *defaultLevelManager) manageLevel(defaultLevelVar *slog.LevelVar, key string, levelFunc LevelFunc,
+	source LevelSource, weakly bool) error {
 	if defaultLevelVar == nil {
 		return errors.New("defaultLevelVar is required")
 	}
@@ -63,20 +156,180 @@ func (lm *defaultLevelManager) ManageLevelFromFunc(defaultLevelVar *slog.LevelVa
 		return errors.New("levelFunc is required")
 	}
 
-	lm.levelVarMap.Store(defaultLevelVar, levelFuncHolder{levelKey: key, levelFunc: levelFunc})
+	funcHolder := levelFuncHolder{levelKey: key, levelFunc: levelFunc, source: source}
+	weakLevelVar := weak.Make(defaultLevelVar)
+	if weakly {
+		lm.levelVarMap.Delete(defaultLevelVar)
+		if _, loaded := lm.levelVarMap.Swap(weakLevelVar, funcHolder); !loaded {
+			// Remove the enrollment once the slog.LevelVar is garbage collected
+			levelVarMap := lm.levelVarMap
+			runtime.AddCleanup(defaultLevelVar, func(weakLevelVar weak.Pointer[slog.LevelVar]) {
+				levelVarMap.Delete(weakLevelVar)
+			}, weakLevelVar)
+		}
+	} else {
+		lm.levelVarMap.Delete(weakLevelVar)
+		lm.levelVarMap.Store(defaultLevelVar, funcHolder)
+	}
 	return nil
 }
 
-// UpdateLevels updates the levels of all enrolled slog.LevelVar objects from their environment variables.
+// Unmanage removes the enrollment of a slog.LevelVar, so that its level is no longer changed by the LevelManager.  It
+// reports whether the slog.LevelVar was enrolled.
+func (lm *defaultLevelManager) Unmanage(levelVar *slog.LevelVar) bool {
+	_, heldStrongly := lm.levelVarMap.LoadAndDelete(levelVar)
+	_, heldWeakly := lm.levelVarMap.LoadAndDelete(weak.Make(levelVar))
+	return heldStrongly || heldWeakly
+}
+
+// Managed returns the key, current level and LevelSource of each enrolled slog.LevelVar, sorted by key.  The
+// LevelSource is LevelSourceEnv for a slog.LevelVar enrolled with ManageLevelFromEnv, and LevelSourceFunc for one
+// enrolled with ManageLevelFromFunc.
+func (lm *defaultLevelManager) Managed() []ManagedLevel {
+	var managed []ManagedLevel
+	lm.rangeLevelVars(func(levelVar *slog.LevelVar, funcHolder levelFuncHolder) {
+		managed = append(managed, ManagedLevel{
+			Key:    funcHolder.levelKey,
+			Level:  levelVar.Level(),
+			Source: funcHolder.source,
+		})
+	})
+	slices.SortStableFunc(managed, func(a, b ManagedLevel) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return managed
+}
+
+// UpdateLevels updates the levels of all enrolled slog.LevelVar objects from their environment variables.  If a
+// LevelFunc panics or returns an invalid level name, a warning is logged and the level is not changed.  The levels of
+// keys with an active override are not changed.
 func (lm *defaultLevelManager) UpdateLevels() {
+	if err := lm.updateLevels(); err != nil {
+		slog.Default().Warn("Could not update levels.",
+			slog.String("error", err.Error()))
+	}
+}
+
+// updateLevels updates the levels of all enrolled slog.LevelVar objects, and returns the errors of the slog.LevelVar
+// objects that could not be updated joined together.
+func (lm *defaultLevelManager) updateLevels() error {
+	var changes []levelChange
+	defer lm.notifyLevelChanges(&changes)
+	lm.overridesMu.Lock()
+	defer lm.overridesMu.Unlock()
+
+	var errs []error
+	lm.rangeLevelVars(func(levelVar *slog.LevelVar, funcHolder levelFuncHolder) {
+		if _, ok := lm.overrides[funcHolder.levelKey]; ok {
+			return
+		}
+		if err := updateLevel(levelVar, funcHolder, &changes); err != nil {
+			errs = append(errs, err)
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Levels returns the current level of each enrolled key.  If the slog.LevelVar objects enrolled with a key have
+// different levels, the lowest level is returned.
+func (lm *defaultLevelManager) Levels() map[string]slog.Level {
+	levels := make(map[string]slog.Level)
+	lm.rangeLevelVars(func(levelVar *slog.LevelVar, funcHolder levelFuncHolder) {
+		level, ok := levels[funcHolder.levelKey]
+		if !ok || levelVar.Level() < level {
+			levels[funcHolder.levelKey] = levelVar.Level()
+		}
+	})
+	return levels
+}
+
+// SetLevel sets the level of all slog.LevelVar objects enrolled with the provided key.  The level is replaced by the
+// next call to UpdateLevels or ResetLevel.  An error is returned if no slog.LevelVar is enrolled with the key.
+func (lm *defaultLevelManager) SetLevel(key string, level slog.Level) error {
+	return lm.setLevel(key, level, LevelSourceSet)
+}
+
+// setLevel sets the level of all slog.LevelVar objects enrolled with the provided key, recording the source of the
+// change.
+func (lm *defaultLevelManager) setLevel(key string, level slog.Level, source LevelSource) error {
+	var changes []levelChange
+	defer lm.notifyLevelChanges(&changes)
+	return lm.setKeyLevel(key, level, source, &changes)
+}
+
+// setKeyLevel sets the level of all slog.LevelVar objects enrolled with the provided key, and appends the changes to
+// changes.
+func (lm *defaultLevelManager) setKeyLevel(key string, level slog.Level, source LevelSource,
+	changes *[]levelChange) error {
+	found := false
+	lm.rangeLevelVars(func(levelVar *slog.LevelVar, funcHolder levelFuncHolder) {
+		if funcHolder.levelKey == key {
+			setLevelVar(levelVar, key, level, source, changes)
+			found = true
+		}
+	})
+	if !found {
+		return fmt.Errorf("no level is managed with key %s", key)
+	}
+	return nil
+}
+
+// ResetLevel updates the levels of all slog.LevelVar objects enrolled with the provided key from their LevelFunc.  An
+// error is returned if no slog.LevelVar is enrolled with the key, or if the LevelFunc panics or returns an invalid
+// level name.
+func (lm *defaultLevelManager) ResetLevel(key string) error {
+	var changes []levelChange
+	defer lm.notifyLevelChanges(&changes)
+	return lm.resetLevel(key, nil, &changes)
+}
+
+// resetLevel updates the levels of all slog.LevelVar objects enrolled with the provided key from their LevelFunc, and
+// appends the changes to changes.  If the LevelFunc does not return a level name, the slog.LevelVar is set to its
+// fallback level, if it has one.
+func (lm *defaultLevelManager) resetLevel(key string, fallback map[*slog.LevelVar]slog.Level,
+	changes *[]levelChange) error {
+	found := false
+	var errs []error
+	lm.rangeLevelVars(func(levelVar *slog.LevelVar, funcHolder levelFuncHolder) {
+		if funcHolder.levelKey != key {
+			return
+		}
+		found = true
+		level, err := levelFromFunc(funcHolder.levelKey, funcHolder.levelFunc)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if level != nil {
+			setLevelVar(levelVar, key, *level, funcHolder.source, changes)
+		} else if fallbackLevel, ok := fallback[levelVar]; ok {
+			setLevelVar(levelVar, key, fallbackLevel, funcHolder.source, changes)
+		}
+	})
+	if !found {
+		return fmt.Errorf("no level is managed with key %s", key)
+	}
+	return errors.Join(errs...)
+}
+
+// rangeLevelVars calls f for each enrolled slog.LevelVar.
+func (lm *defaultLevelManager) rangeLevelVars(f func(levelVar *slog.LevelVar, funcHolder levelFuncHolder)) {
 	lm.levelVarMap.Range(func(key, value interface{}) bool {
 		var defaultLevelVar *slog.LevelVar
 		var funcHolder levelFuncHolder
 		var ok bool
 
 		// Cast the key and value
-		defaultLevelVar, ok = key.(*slog.LevelVar)
-		if !ok {
+		switch levelVar := key.(type) {
+		case *slog.LevelVar:
+			defaultLevelVar = levelVar
+		case weak.Pointer[slog.LevelVar]:
+			defaultLevelVar = levelVar.Value()
+			if defaultLevelVar == nil {
+				// The slog.LevelVar was garbage collected
+				return true
+			}
+		default:
 			panic("Could not cast key to *slog.LevelVar")
 		}
 		funcHolder, ok = value.(levelFuncHolder)
@@ -84,16 +337,25 @@ func (lm *defaultLevelManager) UpdateLevels() {
 			panic("Could not cast value to levelFuncHolder")
 		}
 
-		// Determine the level, defaulting to the current level if the levelFunc does not return a level name
-		level := GetLevelFromFunc(funcHolder.levelKey, funcHolder.levelFunc, defaultLevelVar.Level())
-
-		// Update the level
-		defaultLevelVar.Set(level)
-
+		f(defaultLevelVar, funcHolder)
 		return true
 	})
 }
 
+// updateLevel updates the level of a slog.LevelVar from its LevelFunc, keeping the current level if the LevelFunc does
+// not return a level name, and appends the change to changes.  An error is returned if the LevelFunc panics or returns
+// an invalid level name.
+func updateLevel(levelVar *slog.LevelVar, funcHolder levelFuncHolder, changes *[]levelChange) error {
+	level, err := levelFromFunc(funcHolder.levelKey, funcHolder.levelFunc)
+	if err != nil || level == nil {
+		return err
+	}
+
+	// Update the level
+	setLevelVar(levelVar, funcHolder.levelKey, *level, funcHolder.source, changes)
+	return nil
+}
+
 // getEnvLevelFunc gets the environment variable with the provided level name key.
 func getEnvLevelFunc() LevelFunc {
 	return func(key string) string {
@@ -5,7 +5,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"log/slog"
 	"os"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestLevelManager(t *testing.T) {
@@ -152,3 +154,146 @@ func TestLevelManager_ManageLevel_NilLevelFunc(t *testing.T) {
 	err := levelManager.ManageLevelFromFunc(&levelVar, levelVar1Key, nil)
 	assert.EqualError(t, err, "levelFunc is required")
 }
+
+func TestLevelManager_UpdateLevels_RegisteredLevel(t *testing.T) {
+	levelManager := GetLevelManager()
+	levelVar := slog.LevelVar{}
+	levelVar.Set(slog.LevelInfo)
+
+	levelVarKey := "LEVEL_VAR_REGISTERED"
+	err := levelManager.ManageLevelFromEnv(&levelVar, levelVarKey)
+	assert.NoError(t, err)
+
+	require.NoError(t, os.Setenv(levelVarKey, "trace"))
+
+	levelManager.UpdateLevels()
+	assert.Equal(t, LevelTrace, levelVar.Level())
+}
+
+func TestLevelManager_SetLevel(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar1 := &slog.LevelVar{}
+	levelVar2 := &slog.LevelVar{}
+	levelVar3 := &slog.LevelVar{}
+	levelFunc := func(key string) string {
+		return "WARN"
+	}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar1, "SHARED_LEVEL", levelFunc))
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar2, "SHARED_LEVEL", levelFunc))
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar3, "OTHER_LEVEL", levelFunc))
+	levelManager.UpdateLevels()
+
+	require.NoError(t, levelManager.SetLevel("SHARED_LEVEL", slog.LevelDebug))
+	assert.Equal(t, slog.LevelDebug, levelVar1.Level())
+	assert.Equal(t, slog.LevelDebug, levelVar2.Level())
+	assert.Equal(t, slog.LevelWarn, levelVar3.Level())
+	assert.Equal(t, map[string]slog.Level{
+		"SHARED_LEVEL": slog.LevelDebug,
+		"OTHER_LEVEL":  slog.LevelWarn,
+	}, levelManager.Levels())
+
+	// Levels returns the lowest level of a key
+	levelVar2.Set(LevelTrace)
+	assert.Equal(t, LevelTrace, levelManager.Levels()["SHARED_LEVEL"])
+
+	require.NoError(t, levelManager.ResetLevel("SHARED_LEVEL"))
+	assert.Equal(t, slog.LevelWarn, levelVar1.Level())
+	assert.Equal(t, slog.LevelWarn, levelVar2.Level())
+
+	assert.EqualError(t, levelManager.SetLevel("UNKNOWN_LEVEL", slog.LevelDebug),
+		"no level is managed with key UNKNOWN_LEVEL")
+	assert.EqualError(t, levelManager.ResetLevel("UNKNOWN_LEVEL"), "no level is managed with key UNKNOWN_LEVEL")
+}
+
+func TestLevelManager_Unmanage(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar1 := &slog.LevelVar{}
+	levelVar2 := &slog.LevelVar{}
+	levelVar2.Set(slog.LevelWarn)
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar1, "UNMANAGED_LEVEL", func(key string) string {
+		return "DEBUG"
+	}))
+	require.NoError(t, levelManager.ManageLevelFromEnv(levelVar2, "MANAGED_LEVEL"))
+
+	assert.Equal(t, []ManagedLevel{
+		{Key: "MANAGED_LEVEL", Level: slog.LevelWarn, Source: LevelSourceEnv},
+		{Key: "UNMANAGED_LEVEL", Level: slog.LevelInfo, Source: LevelSourceFunc},
+	}, levelManager.Managed())
+
+	assert.True(t, levelManager.Unmanage(levelVar1))
+	assert.False(t, levelManager.Unmanage(levelVar1))
+	assert.False(t, levelManager.Unmanage(&slog.LevelVar{}))
+
+	// An unmanaged level is no longer updated
+	levelManager.UpdateLevels()
+	assert.Equal(t, slog.LevelInfo, levelVar1.Level())
+	assert.Equal(t, []ManagedLevel{
+		{Key: "MANAGED_LEVEL", Level: slog.LevelWarn, Source: LevelSourceEnv},
+	}, levelManager.Managed())
+}
+
+func TestLevelManager_ManageLevelWeakly(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelFunc := func(key string) string {
+		return "DEBUG"
+	}
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelWeakly(levelVar, "WEAK_LEVEL", levelFunc))
+	require.NoError(t, levelManager.ManageLevelWeakly(&slog.LevelVar{}, "COLLECTED_LEVEL", levelFunc))
+
+	// A weakly held level is updated while it is reachable
+	levelManager.UpdateLevels()
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+
+	// A garbage collected level is removed
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		return len(levelManager.Managed()) == 1
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, "WEAK_LEVEL", levelManager.Managed()[0].Key)
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		count := 0
+		levelManager.levelVarMap.Range(func(key, value any) bool {
+			count++
+			return true
+		})
+		return count == 1
+	}, 5*time.Second, time.Millisecond)
+
+	// Enrolling a weakly held level again replaces its enrollment
+	require.NoError(t, levelManager.ManageLevelFromEnv(levelVar, "STRONG_LEVEL"))
+	assert.Len(t, levelManager.Managed(), 1)
+	assert.Equal(t, "STRONG_LEVEL", levelManager.Managed()[0].Key)
+	require.NoError(t, levelManager.ManageLevelWeakly(levelVar, "WEAK_LEVEL", nil))
+	assert.Len(t, levelManager.Managed(), 1)
+	assert.Equal(t, "WEAK_LEVEL", levelManager.Managed()[0].Key)
+
+	assert.True(t, levelManager.Unmanage(levelVar))
+	assert.Empty(t, levelManager.Managed())
+	runtime.KeepAlive(levelVar)
+}
+
+func TestNewLevelManager(t *testing.T) {
+	levelManager1 := NewLevelManager()
+	levelManager2 := NewLevelManager()
+	assert.NotSame(t, levelManager1, levelManager2)
+	assert.NotSame(t, GetLevelManager(), levelManager1)
+
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager1.ManageLevelFromFunc(levelVar, "INDEPENDENT_LEVEL", func(key string) string {
+		return "DEBUG"
+	}))
+
+	// Only the LevelManager the level is enrolled in updates it
+	levelManager2.UpdateLevels()
+	GetLevelManager().UpdateLevels()
+	assert.Equal(t, slog.LevelInfo, levelVar.Level())
+	assert.Empty(t, levelManager2.Managed())
+
+	levelManager1.UpdateLevels()
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+}
@@ -0,0 +1,154 @@
+package slogx
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LevelOverride is an active temporary level override created with ExtendedLevelManager.OverrideLevel.
+type LevelOverride struct {
+	Key     string
+	Level   slog.Level
+	Expires time.Time
+}
+
+// levelOverride holds an active override, the timer that ends it and the levels of the overridden slog.LevelVar
+// objects before the override, which are restored if the LevelFunc of the key does not return a level name.
+type levelOverride struct {
+	LevelOverride
+	timer    timer
+	previous map[*slog.LevelVar]slog.Level
+}
+
+// OverrideLevel sets the level of all slog.LevelVar objects enrolled with the provided key for the provided duration.
+// UpdateLevels does not change the level while the override is active.  When the duration expires, the level is reset
+// from the LevelFunc of the key, as with ResetLevel, or restored to the level before the override if the LevelFunc
+// does not return a level name.  Overriding a key that is already overridden replaces the
+// override.  An error is returned if the duration is not positive or no slog.LevelVar is enrolled with the key.
+func (lm *defaultLevelManager) OverrideLevel(key string, level slog.Level, duration time.Duration) error {
+	return lm.overrideLevel(key, level, duration, LevelSourceOverride)
+}
+
+// overrideLevel overrides the level of a key, recording the source of the change.
+func (lm *defaultLevelManager) overrideLevel(key string, level slog.Level, duration time.Duration,
+	source LevelSource) error {
+	if duration <= 0 {
+		return errors.New("duration must be positive")
+	}
+
+	var changes []levelChange
+	defer lm.notifyLevelChanges(&changes)
+	lm.overridesMu.Lock()
+	defer lm.overridesMu.Unlock()
+
+	// Keep the levels from before the first override of the key, if it is replaced
+	var previous map[*slog.LevelVar]slog.Level
+	if existing, ok := lm.overrides[key]; ok {
+		previous = existing.previous
+	} else {
+		previous = make(map[*slog.LevelVar]slog.Level)
+		lm.rangeLevelVars(func(levelVar *slog.LevelVar, funcHolder levelFuncHolder) {
+			if funcHolder.levelKey == key {
+				previous[levelVar] = levelVar.Level()
+			}
+		})
+	}
+
+	if err := lm.setKeyLevel(key, level, source, &changes); err != nil {
+		return err
+	}
+
+	if existing, ok := lm.overrides[key]; ok {
+		existing.timer.Stop()
+	}
+	if lm.overrides == nil {
+		lm.overrides = make(map[string]*levelOverride)
+	}
+	override := &levelOverride{
+		LevelOverride: LevelOverride{
+			Key:     key,
+			Level:   level,
+			Expires: lm.clock.Now().Add(duration),
+		},
+		previous: previous,
+	}
+	override.timer = lm.clock.AfterFunc(duration, func() {
+		lm.expireOverride(override)
+	})
+	lm.overrides[key] = override
+	return nil
+}
+
+// ActiveOverrides returns the active level overrides, sorted by key.
+func (lm *defaultLevelManager) ActiveOverrides() []LevelOverride {
+	lm.overridesMu.Lock()
+	defer lm.overridesMu.Unlock()
+
+	overrides := make([]LevelOverride, 0, len(lm.overrides))
+	for _, override := range lm.overrides {
+		overrides = append(overrides, override.LevelOverride)
+	}
+	slices.SortFunc(overrides, func(a, b LevelOverride) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return overrides
+}
+
+// CancelOverride ends the active override of the provided key early, and resets the level from the LevelFunc of the
+// key, or restores the level before the override if the LevelFunc does not return a level name.  An error is returned
+// if the key has no active override.
+func (lm *defaultLevelManager) CancelOverride(key string) error {
+	var changes []levelChange
+	defer lm.notifyLevelChanges(&changes)
+	lm.overridesMu.Lock()
+	defer lm.overridesMu.Unlock()
+
+	override, ok := lm.overrides[key]
+	if !ok {
+		return fmt.Errorf("no level override is active for key %s", key)
+	}
+	override.timer.Stop()
+	delete(lm.overrides, key)
+	return lm.resetLevel(key, override.previous, &changes)
+}
+
+// replaceLevel ends any active override of a key and sets the level of the key, recording the source of the change.
+// Both are done under overridesMu, so the level is not reset from the LevelFunc in between.
+func (lm *defaultLevelManager) replaceLevel(key string, level slog.Level, source LevelSource) error {
+	var changes []levelChange
+	defer lm.notifyLevelChanges(&changes)
+	lm.overridesMu.Lock()
+	defer lm.overridesMu.Unlock()
+
+	if err := lm.setKeyLevel(key, level, source, &changes); err != nil {
+		return err
+	}
+	if override, ok := lm.overrides[key]; ok {
+		override.timer.Stop()
+		delete(lm.overrides, key)
+	}
+	return nil
+}
+
+// expireOverride ends an override when its duration expires, unless it was replaced or cancelled.
+func (lm *defaultLevelManager) expireOverride(override *levelOverride) {
+	var changes []levelChange
+	defer lm.notifyLevelChanges(&changes)
+	lm.overridesMu.Lock()
+	defer lm.overridesMu.Unlock()
+
+	if lm.overrides[override.Key] != override {
+		return
+	}
+	delete(lm.overrides, override.Key)
+
+	if err := lm.resetLevel(override.Key, override.previous, &changes); err != nil {
+		slog.Default().Warn("Could not reset level after override expired.",
+			slog.String("key", override.Key),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,134 @@
+package slogx
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelManager_OverrideLevel(t *testing.T) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	var levelName atomic.Value
+	levelName.Store("INFO")
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "OVERRIDDEN_LEVEL", func(key string) string {
+		return levelName.Load().(string)
+	}))
+	levelManager.UpdateLevels()
+
+	require.NoError(t, levelManager.OverrideLevel("OVERRIDDEN_LEVEL", slog.LevelDebug, 50*time.Millisecond))
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+
+	overrides := levelManager.ActiveOverrides()
+	require.Len(t, overrides, 1)
+	assert.Equal(t, "OVERRIDDEN_LEVEL", overrides[0].Key)
+	assert.Equal(t, slog.LevelDebug, overrides[0].Level)
+	assert.Equal(t, clock.Now().Add(50*time.Millisecond), overrides[0].Expires)
+
+	// UpdateLevels does not replace an overridden level
+	levelName.Store("WARN")
+	levelManager.UpdateLevels()
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+
+	// The level is reset from the LevelFunc when the override expires
+	assert.Equal(t, 1, clock.fireTimers())
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+	assert.Empty(t, levelManager.ActiveOverrides())
+}
+
+func TestLevelManager_CancelOverride(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar1 := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar1, "OVERRIDDEN_LEVEL_1", func(key string) string {
+		return "ERROR"
+	}))
+	levelVar2 := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar2, "OVERRIDDEN_LEVEL_2", func(key string) string {
+		return "ERROR"
+	}))
+	levelManager.UpdateLevels()
+
+	require.NoError(t, levelManager.OverrideLevel("OVERRIDDEN_LEVEL_2", slog.LevelDebug, time.Hour))
+	require.NoError(t, levelManager.OverrideLevel("OVERRIDDEN_LEVEL_1", slog.LevelDebug, time.Hour))
+	overrides := levelManager.ActiveOverrides()
+	require.Len(t, overrides, 2)
+	assert.Equal(t, "OVERRIDDEN_LEVEL_1", overrides[0].Key)
+	assert.Equal(t, "OVERRIDDEN_LEVEL_2", overrides[1].Key)
+
+	require.NoError(t, levelManager.CancelOverride("OVERRIDDEN_LEVEL_1"))
+	assert.Equal(t, slog.LevelError, levelVar1.Level())
+	assert.Equal(t, slog.LevelDebug, levelVar2.Level())
+	assert.Len(t, levelManager.ActiveOverrides(), 1)
+
+	assert.EqualError(t, levelManager.CancelOverride("OVERRIDDEN_LEVEL_1"),
+		"no level override is active for key OVERRIDDEN_LEVEL_1")
+	require.NoError(t, levelManager.CancelOverride("OVERRIDDEN_LEVEL_2"))
+	assert.Equal(t, slog.LevelError, levelVar2.Level())
+}
+
+func TestLevelManager_OverrideLevel_Replace(t *testing.T) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "OVERRIDDEN_LEVEL", func(key string) string {
+		return "ERROR"
+	}))
+
+	// Replacing an override stops the first one from expiring
+	require.NoError(t, levelManager.OverrideLevel("OVERRIDDEN_LEVEL", slog.LevelDebug, 10*time.Millisecond))
+	require.NoError(t, levelManager.OverrideLevel("OVERRIDDEN_LEVEL", LevelTrace, time.Hour))
+	require.Len(t, clock.timers, 2)
+	assert.True(t, clock.timers[0].stopped.Load())
+	assert.Equal(t, LevelTrace, levelVar.Level())
+	assert.Equal(t, clock.Now().Add(time.Hour), levelManager.ActiveOverrides()[0].Expires)
+
+	// Only the replacing override expires
+	assert.Equal(t, 1, clock.fireTimers())
+	assert.Equal(t, slog.LevelError, levelVar.Level())
+}
+
+func TestLevelManager_OverrideLevel_Errors(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "OVERRIDDEN_LEVEL", getEnvLevelFunc()))
+
+	assert.EqualError(t, levelManager.OverrideLevel("OVERRIDDEN_LEVEL", slog.LevelDebug, 0),
+		"duration must be positive")
+	assert.EqualError(t, levelManager.OverrideLevel("UNKNOWN_LEVEL", slog.LevelDebug, time.Hour),
+		"no level is managed with key UNKNOWN_LEVEL")
+	assert.Empty(t, levelManager.ActiveOverrides())
+}
+
+func TestLevelManager_OverrideLevel_UnsetLevelName(t *testing.T) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	// The level is set by the builder, and the environment variable is not set
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(slog.LevelWarn)
+	require.NoError(t, levelManager.ManageLevelFromEnv(levelVar, "OVERRIDDEN_UNSET_LEVEL"))
+
+	require.NoError(t, levelManager.OverrideLevel("OVERRIDDEN_UNSET_LEVEL", slog.LevelInfo, time.Minute))
+	require.NoError(t, levelManager.OverrideLevel("OVERRIDDEN_UNSET_LEVEL", slog.LevelDebug, time.Minute))
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+
+	// The level from before the first override is restored when the override expires
+	assert.Equal(t, 1, clock.fireTimers())
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+	assert.Empty(t, levelManager.ActiveOverrides())
+
+	// and when it is cancelled
+	require.NoError(t, levelManager.OverrideLevel("OVERRIDDEN_UNSET_LEVEL", slog.LevelDebug, time.Minute))
+	require.NoError(t, levelManager.CancelOverride("OVERRIDDEN_UNSET_LEVEL"))
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+	assert.Equal(t, 0, clock.fireTimers())
+}
@@ -0,0 +1,108 @@
+package slogx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// maxPollingBackoffShift limits the polling backoff after consecutive failed updates to 32 times the interval.
+const maxPollingBackoffShift = 5
+
+// clock is the source of time for polling and level overrides, so that tests can use a fake clock.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) timer
+}
+
+// timer is a timer started with clock.AfterFunc.
+type timer interface {
+	Stop() bool
+}
+
+// realClock is a clock that uses the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) timer {
+	return time.AfterFunc(d, f)
+}
+
+// StartPolling calls UpdateLevels every interval in a new goroutine, until the Context is cancelled or Stop is called.
+// Up to 10% of jitter is added to each interval, so that many processes do not poll a configuration service at the
+// same time.  If a LevelFunc panics or returns an invalid level name, a warning is logged and the interval is doubled
+// for each consecutive failed update, up to 32 times the interval.  An error is returned if the interval is not
+// positive or polling is already started.
+func (lm *defaultLevelManager) StartPolling(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	if lm.pollingDone != nil {
+		return errors.New("polling is already started")
+	}
+
+	ctx, lm.cancelPolling = context.WithCancel(ctx)
+	lm.pollingDone = make(chan struct{})
+	go lm.poll(ctx, interval, lm.pollingDone)
+	return nil
+}
+
+// Stop stops polling and signal handling, and waits for their goroutines to finish.
+func (lm *defaultLevelManager) Stop() {
+	lm.mu.Lock()
+	cancels := []context.CancelFunc{lm.cancelPolling, lm.cancelSignals}
+	dones := []chan struct{}{lm.pollingDone, lm.signalsDone}
+	lm.cancelPolling, lm.pollingDone = nil, nil
+	lm.cancelSignals, lm.signalsDone = nil, nil
+	lm.mu.Unlock()
+
+	for i, cancel := range cancels {
+		if cancel != nil {
+			cancel()
+			<-dones[i]
+		}
+	}
+}
+
+// poll updates the levels after every polling delay until the Context is cancelled.
+func (lm *defaultLevelManager) poll(ctx context.Context, interval time.Duration, done chan struct{}) {
+	defer close(done)
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-lm.clock.After(pollingDelay(interval, failures)):
+		}
+
+		if err := lm.updateLevels(); err != nil {
+			failures++
+			slog.Default().Warn("Could not update levels.",
+				slog.Int("failures", failures),
+				slog.String("error", err.Error()))
+		} else {
+			failures = 0
+		}
+	}
+}
+
+// pollingDelay returns the interval doubled for each failure, with up to 10% of jitter added.
+func pollingDelay(interval time.Duration, failures int) time.Duration {
+	delay := interval << min(failures, maxPollingBackoffShift)
+	return delay + rand.N(delay/10+1)
+}
@@ -0,0 +1,243 @@
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock that sends each requested wait to the test, which fires it.  Timers started with AfterFunc are
+// kept until the test fires them with fireTimers.  The time is fixed at now.
+type fakeClock struct {
+	now   time.Time
+	waits chan fakeWait
+
+	mu     sync.Mutex
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	d       time.Duration
+	f       func()
+	stopped atomic.Bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	return !t.stopped.Swap(true)
+}
+
+type fakeWait struct {
+	d time.Duration
+	c chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:   time.Date(2025, time.April, 11, 9, 30, 0, 0, time.UTC),
+		waits: make(chan fakeWait),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	w := fakeWait{d: d, c: make(chan time.Time, 1)}
+	c.waits <- w
+	return w.c
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) timer {
+	t := &fakeTimer{d: d, f: f}
+	c.mu.Lock()
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+	return t
+}
+
+// fireTimers calls the functions of the timers that are not stopped, and returns the number of timers fired.
+func (c *fakeClock) fireTimers() int {
+	c.mu.Lock()
+	timers := c.timers
+	c.timers = nil
+	c.mu.Unlock()
+
+	fired := 0
+	for _, t := range timers {
+		if t.Stop() {
+			t.f()
+			fired++
+		}
+	}
+	return fired
+}
+
+// next returns the next wait requested by the polling goroutine.
+func (c *fakeClock) next(t *testing.T) fakeWait {
+	t.Helper()
+	select {
+	case w := <-c.waits:
+		return w
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "polling did not wait")
+		return fakeWait{}
+	}
+}
+
+// fire fires a wait, and returns the following wait once the polling goroutine requests it.
+func (c *fakeClock) fire(t *testing.T, w fakeWait) fakeWait {
+	t.Helper()
+	w.c <- time.Now()
+	return c.next(t)
+}
+
+func newTestLevelManager(clock clock) *defaultLevelManager {
+	levelManager := newLevelManager()
+	levelManager.clock = clock
+	return levelManager
+}
+
+func TestLevelManager_StartPolling(t *testing.T) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	var levelName atomic.Value
+	levelName.Store("INFO")
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "POLLED_LEVEL", func(key string) string {
+		return levelName.Load().(string)
+	}))
+
+	interval := 10 * time.Second
+	require.NoError(t, levelManager.StartPolling(context.Background(), interval))
+	defer levelManager.Stop()
+	assert.Error(t, levelManager.StartPolling(context.Background(), interval))
+
+	// The first update happens after the interval
+	levelName.Store("DEBUG")
+	w := clock.next(t)
+	assert.Equal(t, slog.LevelInfo, levelVar.Level())
+	assert.GreaterOrEqual(t, w.d, interval)
+	assert.LessOrEqual(t, w.d, interval+interval/10)
+
+	w = clock.fire(t, w)
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+
+	levelName.Store("ERROR")
+	clock.fire(t, w)
+	assert.Equal(t, slog.LevelError, levelVar.Level())
+}
+
+func TestLevelManager_StartPolling_Backoff(t *testing.T) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	var fail atomic.Bool
+	fail.Store(true)
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "POLLED_LEVEL", func(key string) string {
+		if fail.Load() {
+			panic("parameter store unavailable")
+		}
+		return "WARN"
+	}))
+
+	interval := time.Second
+	require.NoError(t, levelManager.StartPolling(context.Background(), interval))
+	defer levelManager.Stop()
+
+	// The delay doubles after each failed update, up to 32 times the interval
+	w := clock.next(t)
+	for _, factor := range []time.Duration{2, 4, 8, 16, 32, 32} {
+		w = clock.fire(t, w)
+		assert.GreaterOrEqual(t, w.d, factor*interval)
+		assert.LessOrEqual(t, w.d, factor*interval+factor*interval/10)
+	}
+	assert.Equal(t, slog.LevelInfo, levelVar.Level())
+
+	// A successful update resets the delay
+	fail.Store(false)
+	w = clock.fire(t, w)
+	assert.LessOrEqual(t, w.d, interval+interval/10)
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+}
+
+func TestLevelManager_StartPolling_InvalidLevelBacksOff(t *testing.T) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "POLLED_LEVEL", func(key string) string {
+		return "INVALID_LEVEL"
+	}))
+
+	require.NoError(t, levelManager.StartPolling(context.Background(), time.Second))
+	defer levelManager.Stop()
+
+	w := clock.fire(t, clock.next(t))
+	assert.GreaterOrEqual(t, w.d, 2*time.Second)
+	assert.Equal(t, slog.LevelInfo, levelVar.Level())
+}
+
+func TestLevelManager_StartPolling_Stop(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	assert.Error(t, levelManager.StartPolling(context.Background(), 0))
+
+	// Stopping a level manager that is not polling does nothing
+	levelManager.Stop()
+
+	var calls atomic.Int32
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "POLLED_LEVEL", func(key string) string {
+		calls.Add(1)
+		return "DEBUG"
+	}))
+
+	require.NoError(t, levelManager.StartPolling(context.Background(), time.Millisecond))
+	assert.Eventually(t, func() bool {
+		return calls.Load() > 0
+	}, 5*time.Second, time.Millisecond)
+	levelManager.Stop()
+
+	// No updates happen after Stop returns
+	stopped := calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load())
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+
+	// Cancelling the Context stops polling, and polling can be started again
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, levelManager.StartPolling(ctx, time.Millisecond))
+	cancel()
+	levelManager.Stop()
+	require.NoError(t, levelManager.StartPolling(context.Background(), time.Millisecond))
+	levelManager.Stop()
+}
+
+func TestLevelManager_UpdateLevels_PanickingLevelFunc(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar1 := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar1, "PANICKING_LEVEL", func(key string) string {
+		panic("unavailable")
+	}))
+	levelVar2 := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar2, "WORKING_LEVEL", func(key string) string {
+		return "DEBUG"
+	}))
+
+	assert.NotPanics(t, levelManager.UpdateLevels)
+	assert.Equal(t, slog.LevelInfo, levelVar1.Level())
+	assert.Equal(t, slog.LevelDebug, levelVar2.Level())
+
+	err := levelManager.updateLevels()
+	assert.ErrorContains(t, err, "levelFunc for key PANICKING_LEVEL panicked: unavailable")
+}
@@ -0,0 +1,117 @@
+package slogx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+)
+
+// LevelSignalOptions are options for ExtendedLevelManager.StartSignals.  A zero LevelSignalOptions consists entirely of
+// default values.
+type LevelSignalOptions struct {
+	// DecreaseSignal steps every managed level down to the next registered level, e.g. from INFO to DEBUG, so that
+	// more is logged.  If nil, SIGUSR1 is used on Unix platforms.
+	DecreaseSignal os.Signal
+
+	// IncreaseSignal steps every managed level up to the next registered level, e.g. from DEBUG to INFO, so that less
+	// is logged.  If nil, SIGUSR2 is used on Unix platforms.
+	IncreaseSignal os.Signal
+
+	// UpdateSignal calls UpdateLevels, which restores the levels from their LevelFuncs.  If nil, no signal is used.
+	UpdateSignal os.Signal
+}
+
+// StartSignals changes the managed levels in a new goroutine when the process receives one of the configured signals,
+// until the Context is cancelled or Stop is called.  This lets an on-call engineer enable debug logging in a running
+// process with "kill -USR1 <pid>".  Stepped levels are replaced by the next call to UpdateLevels.  The level of a key
+// with an active override is stepped from the override level, and is restored as usual when the override ends.  An
+// error is returned if no signals are configured or signal handling is already started.
+func (lm *defaultLevelManager) StartSignals(ctx context.Context, opts *LevelSignalOptions) error {
+	options := LevelSignalOptions{
+		DecreaseSignal: defaultDecreaseSignal,
+		IncreaseSignal: defaultIncreaseSignal,
+	}
+	if opts != nil {
+		if opts.DecreaseSignal != nil {
+			options.DecreaseSignal = opts.DecreaseSignal
+		}
+		if opts.IncreaseSignal != nil {
+			options.IncreaseSignal = opts.IncreaseSignal
+		}
+		options.UpdateSignal = opts.UpdateSignal
+	}
+
+	var signals []os.Signal
+	for _, sig := range []os.Signal{options.DecreaseSignal, options.IncreaseSignal, options.UpdateSignal} {
+		if sig != nil {
+			signals = append(signals, sig)
+		}
+	}
+	if len(signals) == 0 {
+		return errors.New("no signals are configured")
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	if lm.signalsDone != nil {
+		return errors.New("signal handling is already started")
+	}
+
+	// Register the signals before returning, so that no signal is missed
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, signals...)
+
+	ctx, lm.cancelSignals = context.WithCancel(ctx)
+	lm.signalsDone = make(chan struct{})
+	go lm.handleSignals(ctx, options, signalChan, lm.signalsDone)
+	return nil
+}
+
+// handleSignals changes the levels for each signal received until the Context is cancelled.
+func (lm *defaultLevelManager) handleSignals(ctx context.Context, opts LevelSignalOptions,
+	signalChan chan os.Signal, done chan struct{}) {
+	defer close(done)
+	defer signal.Stop(signalChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signalChan:
+			switch sig {
+			case opts.DecreaseSignal:
+				lm.stepLevels(true)
+			case opts.IncreaseSignal:
+				lm.stepLevels(false)
+			case opts.UpdateSignal:
+				lm.UpdateLevels()
+			}
+		}
+	}
+}
+
+// stepLevels sets every managed level to the next registered level below it if down is true, or above it otherwise.
+// Levels that are already the lowest or highest registered level are not changed.  The level of a key with an active
+// override is stepped from the override level, which the override keeps until it ends.
+func (lm *defaultLevelManager) stepLevels(down bool) {
+	var changes []levelChange
+	defer lm.notifyLevelChanges(&changes)
+	lm.overridesMu.Lock()
+	defer lm.overridesMu.Unlock()
+
+	for _, override := range lm.overrides {
+		if level, ok := adjacentLevel(override.Level, down); ok {
+			override.Level = level
+		}
+	}
+	lm.rangeLevelVars(func(levelVar *slog.LevelVar, funcHolder levelFuncHolder) {
+		if override, ok := lm.overrides[funcHolder.levelKey]; ok {
+			setLevelVar(levelVar, funcHolder.levelKey, override.Level, LevelSourceSignal, &changes)
+		} else if level, ok := adjacentLevel(levelVar.Level(), down); ok {
+			setLevelVar(levelVar, funcHolder.levelKey, level, LevelSourceSignal, &changes)
+		}
+	})
+}
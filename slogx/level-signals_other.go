@@ -0,0 +1,12 @@
+//go:build !unix
+
+package slogx
+
+import "os"
+
+// There are no default signals for ExtendedLevelManager.StartSignals, because SIGUSR1 and SIGUSR2 are only available on
+// Unix platforms.
+var (
+	defaultDecreaseSignal os.Signal
+	defaultIncreaseSignal os.Signal
+)
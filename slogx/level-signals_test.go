@@ -0,0 +1,160 @@
+//go:build unix
+
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendSignal sends a signal to the test process.
+func sendSignal(t *testing.T, sig syscall.Signal) {
+	t.Helper()
+	require.NoError(t, syscall.Kill(os.Getpid(), sig))
+}
+
+// assertLevelEventually asserts that the level of levelVar becomes the expected level.
+func assertLevelEventually(t *testing.T, expected slog.Level, levelVar *slog.LevelVar) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		return levelVar.Level() == expected
+	}, 5*time.Second, time.Millisecond, "expected level %s", expected)
+}
+
+func TestLevelManager_StartSignals(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "SIGNAL_LEVEL", func(key string) string {
+		return "WARN"
+	}))
+	levelManager.UpdateLevels()
+
+	require.NoError(t, levelManager.StartSignals(context.Background(), nil))
+	defer levelManager.Stop()
+	assert.Error(t, levelManager.StartSignals(context.Background(), nil))
+
+	// SIGUSR1 steps down through the registered levels
+	sendSignal(t, syscall.SIGUSR1)
+	assertLevelEventually(t, LevelNotice, levelVar)
+	sendSignal(t, syscall.SIGUSR1)
+	assertLevelEventually(t, slog.LevelInfo, levelVar)
+	sendSignal(t, syscall.SIGUSR1)
+	assertLevelEventually(t, slog.LevelDebug, levelVar)
+	sendSignal(t, syscall.SIGUSR1)
+	assertLevelEventually(t, LevelTrace, levelVar)
+
+	// SIGUSR2 steps up
+	sendSignal(t, syscall.SIGUSR2)
+	assertLevelEventually(t, slog.LevelDebug, levelVar)
+}
+
+func TestLevelManager_StartSignals_Options(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "SIGNAL_LEVEL", func(key string) string {
+		return "ERROR"
+	}))
+	levelManager.UpdateLevels()
+
+	require.NoError(t, levelManager.StartSignals(context.Background(), &LevelSignalOptions{
+		DecreaseSignal: syscall.SIGUSR2,
+		IncreaseSignal: syscall.SIGUSR1,
+		UpdateSignal:   syscall.SIGHUP,
+	}))
+	defer levelManager.Stop()
+
+	sendSignal(t, syscall.SIGUSR2)
+	assertLevelEventually(t, slog.LevelWarn, levelVar)
+	sendSignal(t, syscall.SIGUSR2)
+	assertLevelEventually(t, LevelNotice, levelVar)
+	sendSignal(t, syscall.SIGUSR1)
+	assertLevelEventually(t, slog.LevelWarn, levelVar)
+
+	// SIGHUP restores the level from the LevelFunc
+	sendSignal(t, syscall.SIGHUP)
+	assertLevelEventually(t, slog.LevelError, levelVar)
+}
+
+func TestLevelManager_StartSignals_Stop(t *testing.T) {
+	levelManager := newTestLevelManager(realClock{})
+
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(slog.LevelInfo)
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "SIGNAL_LEVEL", getEnvLevelFunc()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, levelManager.StartSignals(ctx, nil))
+	cancel()
+	levelManager.Stop()
+
+	// Signal handling can be started again
+	require.NoError(t, levelManager.StartSignals(context.Background(), nil))
+	levelManager.Stop()
+
+	// A stopped level manager ignores signals.  Another level manager handles them, so they do not kill the test
+	// process.
+	other := newTestLevelManager(realClock{})
+	require.NoError(t, other.StartSignals(context.Background(), nil))
+	defer other.Stop()
+
+	sendSignal(t, syscall.SIGUSR1)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, slog.LevelInfo, levelVar.Level())
+}
+
+func TestLevelManager_StepLevels_Override(t *testing.T) {
+	clock := newFakeClock()
+	levelManager := newTestLevelManager(clock)
+
+	levelVar := &slog.LevelVar{}
+	require.NoError(t, levelManager.ManageLevelFromFunc(levelVar, "STEP_LEVEL", func(key string) string {
+		return "WARN"
+	}))
+	levelManager.UpdateLevels()
+	require.NoError(t, levelManager.OverrideLevel("STEP_LEVEL", slog.LevelInfo, time.Minute))
+
+	// The override level is stepped, and the override is kept
+	levelManager.stepLevels(true)
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+	overrides := levelManager.ActiveOverrides()
+	require.Len(t, overrides, 1)
+	assert.Equal(t, slog.LevelDebug, overrides[0].Level)
+
+	// The level is reset when the override expires
+	assert.Equal(t, 1, clock.fireTimers())
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+	assert.Empty(t, levelManager.ActiveOverrides())
+}
+
+func TestAdjacentLevel(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		down     bool
+		expected slog.Level
+		ok       bool
+	}{
+		{level: slog.LevelInfo, down: true, expected: slog.LevelDebug, ok: true},
+		{level: slog.LevelInfo, down: false, expected: LevelNotice, ok: true},
+		{level: slog.LevelInfo + 1, down: true, expected: slog.LevelInfo, ok: true},
+		{level: slog.LevelInfo + 1, down: false, expected: LevelNotice, ok: true},
+		{level: LevelTrace, down: true, ok: false},
+		{level: LevelPanic, down: false, ok: false},
+	}
+
+	for _, tt := range tests {
+		level, ok := adjacentLevel(tt.level, tt.down)
+		assert.Equal(t, tt.ok, ok, tt.level.String())
+		if tt.ok {
+			assert.Equal(t, tt.expected, level, tt.level.String())
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package slogx
+
+import "syscall"
+
+// The default signals for ExtendedLevelManager.StartSignals.
+var (
+	defaultDecreaseSignal = syscall.SIGUSR1
+	defaultIncreaseSignal = syscall.SIGUSR2
+)
@@ -2,29 +2,183 @@ package slogx
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
+	"sync"
 )
 
-// GetLevelByName returns a slog.Level object for the provided level name.
+// Levels in addition to the slog levels.  They are registered by default, so GetLevelByName parses them and loggers
+// built with LoggerBuilder render them by name.
+const (
+	LevelTrace  slog.Level = -8
+	LevelNotice slog.Level = 2
+	LevelFatal  slog.Level = 12
+	LevelPanic  slog.Level = 16
+)
+
+// levelRegistry holds the named levels and level name aliases.  Names are stored in upper case.  Aliases are only
+// used for parsing, so they are not in byLevel.
+var levelRegistry = struct {
+	sync.RWMutex
+	byName  map[string]slog.Level
+	byLevel map[slog.Level]string
+}{
+	byName: map[string]slog.Level{
+		"TRACE":  LevelTrace,
+		"DEBUG":  slog.LevelDebug,
+		"INFO":   slog.LevelInfo,
+		"NOTICE": LevelNotice,
+		"WARN":   slog.LevelWarn,
+		"ERROR":  slog.LevelError,
+		"FATAL":  LevelFatal,
+		"PANIC":  LevelPanic,
+
+		// Aliases
+		"WARNING": slog.LevelWarn,
+		"ERR":     slog.LevelError,
+	},
+	byLevel: map[slog.Level]string{
+		LevelTrace:      "TRACE",
+		slog.LevelDebug: "DEBUG",
+		slog.LevelInfo:  "INFO",
+		LevelNotice:     "NOTICE",
+		slog.LevelWarn:  "WARN",
+		slog.LevelError: "ERROR",
+		LevelFatal:      "FATAL",
+		LevelPanic:      "PANIC",
+	},
+}
+
+// RegisterLevel registers a named level, so that GetLevelByName parses the name and loggers built with LoggerBuilder
+// render the level by name rather than as an offset from a slog level, such as "DEBUG-4".  Names are case-insensitive
+// and must start with a letter and contain only letters, digits and underscores.  An error is returned if the name or
+// the level is already registered, unless it is registered with the same level and name.
+func RegisterLevel(name string, level slog.Level) error {
+	if !isValidLevelName(name) {
+		return fmt.Errorf("invalid level name: %q", name)
+	}
+	upperName := strings.ToUpper(name)
+
+	levelRegistry.Lock()
+	defer levelRegistry.Unlock()
+
+	if existingLevel, ok := levelRegistry.byName[upperName]; ok {
+		if existingLevel == level {
+			return nil
+		}
+		return fmt.Errorf("level name %s is already registered with level %d", upperName, int(existingLevel))
+	}
+	if existingName, ok := levelRegistry.byLevel[level]; ok {
+		return fmt.Errorf("level %d is already registered with level name %s", int(level), existingName)
+	}
+
+	levelRegistry.byName[upperName] = level
+	levelRegistry.byLevel[level] = upperName
+	return nil
+}
+
+// LevelName returns the registered name of the provided level.  If the level is not registered, the result of
+// slog.Level.String is returned.
+func LevelName(level slog.Level) string {
+	switch level {
+	case slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError:
+		// The slog levels are always registered with their slog names
+		return level.String()
+	}
+
+	levelRegistry.RLock()
+	name, ok := levelRegistry.byLevel[level]
+	levelRegistry.RUnlock()
+	if ok {
+		return name
+	}
+	return level.String()
+}
+
+// adjacentLevel returns the closest registered level below the provided level if down is true, or above it
+// otherwise.  ok is false if there is no registered level in that direction.
+func adjacentLevel(level slog.Level, down bool) (adjacent slog.Level, ok bool) {
+	levelRegistry.RLock()
+	defer levelRegistry.RUnlock()
+
+	for registered := range levelRegistry.byLevel {
+		if down && registered < level && (!ok || registered > adjacent) ||
+			!down && registered > level && (!ok || registered < adjacent) {
+			adjacent, ok = registered, true
+		}
+	}
+	return adjacent, ok
+}
+
+// isValidLevelName reports whether the name can be registered as a level name.
+func isValidLevelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// GetLevelByName returns a slog.Level object for the provided level name.  The slog level names, the aliases WARNING
+// and ERR, and any level names registered with RegisterLevel are recognised, case-insensitively.  A level name may
+// have a numeric offset, such as "DEBUG-2" or "INFO+1", and a level may be given as a plain number, such as "-4", so
+// every level produced by slog.Level.String, slog.Level.MarshalText and LevelName can be parsed.
 // If the level name is not valid, an error is returned
 func GetLevelByName(levelName string) (*slog.Level, error) {
-	levelNameTrim := strings.TrimSpace(levelName)
-	if strings.EqualFold(levelNameTrim, slog.LevelDebug.String()) {
-		levelDebug := slog.LevelDebug
-		return &levelDebug, nil
-	} else if strings.EqualFold(levelNameTrim, slog.LevelInfo.String()) {
-		levelInfo := slog.LevelInfo
-		return &levelInfo, nil
-	} else if strings.EqualFold(levelNameTrim, slog.LevelWarn.String()) {
-		levelWarn := slog.LevelWarn
-		return &levelWarn, nil
-	} else if strings.EqualFold(levelNameTrim, slog.LevelError.String()) {
-		levelError := slog.LevelError
-		return &levelError, nil
-	} else {
+	level, ok := parseLevel(strings.ToUpper(strings.TrimSpace(levelName)))
+	if !ok {
 		return nil, errors.New("invalid level name: " + levelName)
 	}
+	return &level, nil
+}
+
+// parseLevel parses an upper case level name, a level name with an offset or a number.
+func parseLevel(name string) (slog.Level, bool) {
+	if level, ok := lookupLevelName(name); ok {
+		return level, true
+	}
+
+	// A plain number
+	if n, err := strconv.Atoi(name); err == nil {
+		return slog.Level(n), true
+	}
+
+	// A level name followed by a signed offset
+	i := strings.LastIndexAny(name, "+-")
+	if i <= 0 {
+		return 0, false
+	}
+	base, ok := lookupLevelName(name[:i])
+	if !ok {
+		return 0, false
+	}
+	offset, err := strconv.Atoi(name[i:])
+	if err != nil {
+		return 0, false
+	}
+	level := int(base) + offset
+	if (offset > 0 && level < int(base)) || (offset < 0 && level > int(base)) {
+		// Overflow
+		return 0, false
+	}
+	return slog.Level(level), true
+}
+
+// lookupLevelName returns the level registered with the upper case name.
+func lookupLevelName(name string) (slog.Level, bool) {
+	levelRegistry.RLock()
+	level, ok := levelRegistry.byName[name]
+	levelRegistry.RUnlock()
+	return level, ok
 }
 
 // GetLevelFromEnv returns a slog.Level object for the provided environment variable key.
@@ -54,3 +208,23 @@ func GetLevelFromFunc(levelKey string, levelFunc LevelFunc, defaultLevel slog.Le
 	}
 	return *level
 }
+
+// levelFromFunc returns the level named by the LevelFunc for the provided key, or nil if the LevelFunc does not return
+// a level name.  An error is returned if the LevelFunc panics or returns an invalid level name.
+func levelFromFunc(levelKey string, levelFunc LevelFunc) (level *slog.Level, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			level, err = nil, fmt.Errorf("levelFunc for key %s panicked: %v", levelKey, r)
+		}
+	}()
+
+	levelName := levelFunc(levelKey)
+	if levelName == "" {
+		return nil, nil
+	}
+	level, err = GetLevelByName(levelName)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", levelKey, err)
+	}
+	return level, nil
+}
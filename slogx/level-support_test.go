@@ -5,7 +5,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"log/slog"
+	"math"
 	"os"
+	"strconv"
 	"testing"
 )
 
@@ -62,3 +64,149 @@ func TestGetLevelValueByName_InvalidLevel(t *testing.T) {
 	assert.Nil(t, actualLevel)
 	assert.Error(t, err)
 }
+
+func TestGetLevelByName_RegisteredLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected slog.Level
+	}{
+		{name: "TRACE", expected: LevelTrace},
+		{name: "debug", expected: slog.LevelDebug},
+		{name: "Notice", expected: LevelNotice},
+		{name: "WARN", expected: slog.LevelWarn},
+		{name: "fatal", expected: LevelFatal},
+		{name: "PANIC", expected: LevelPanic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualLevel, err := GetLevelByName(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *actualLevel)
+		})
+	}
+}
+
+// unregisterLevel removes a level registered by a test, so that it does not affect other tests.
+func unregisterLevel(t *testing.T, name string) {
+	t.Cleanup(func() {
+		levelRegistry.Lock()
+		defer levelRegistry.Unlock()
+		delete(levelRegistry.byLevel, levelRegistry.byName[name])
+		delete(levelRegistry.byName, name)
+	})
+}
+
+func TestRegisterLevel(t *testing.T) {
+	require.NoError(t, RegisterLevel("Audit", slog.Level(10)))
+	unregisterLevel(t, "AUDIT")
+
+	actualLevel, err := GetLevelByName("AUDIT")
+	require.NoError(t, err)
+	assert.Equal(t, slog.Level(10), *actualLevel)
+	assert.Equal(t, "AUDIT", LevelName(slog.Level(10)))
+
+	// Registering the same name and level again is allowed
+	assert.NoError(t, RegisterLevel("audit", slog.Level(10)))
+}
+
+func TestRegisterLevel_Errors(t *testing.T) {
+	assert.Error(t, RegisterLevel("", slog.Level(11)))
+	assert.Error(t, RegisterLevel("1LEVEL", slog.Level(11)))
+	assert.Error(t, RegisterLevel("DEBUG+1", slog.Level(11)))
+	assert.Error(t, RegisterLevel("TWO WORDS", slog.Level(11)))
+	assert.EqualError(t, RegisterLevel("info", slog.Level(11)), "level name INFO is already registered with level 0")
+	assert.EqualError(t, RegisterLevel("VERBOSE", slog.LevelDebug),
+		"level -4 is already registered with level name DEBUG")
+}
+
+func TestLevelName(t *testing.T) {
+	assert.Equal(t, "TRACE", LevelName(LevelTrace))
+	assert.Equal(t, "DEBUG", LevelName(slog.LevelDebug))
+	assert.Equal(t, "INFO", LevelName(slog.LevelInfo))
+	assert.Equal(t, "NOTICE", LevelName(LevelNotice))
+	assert.Equal(t, "FATAL", LevelName(LevelFatal))
+	assert.Equal(t, "INFO+1", LevelName(slog.LevelInfo+1))
+}
+
+func TestGetLevelByName_OffsetsAndNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected slog.Level
+	}{
+		{name: "DEBUG-2", expected: slog.LevelDebug - 2},
+		{name: "INFO+1", expected: slog.LevelInfo + 1},
+		{name: "info+0", expected: slog.LevelInfo},
+		{name: "ERROR+4", expected: LevelFatal},
+		{name: "TRACE+1", expected: LevelTrace + 1},
+		{name: "warning-1", expected: slog.LevelWarn - 1},
+		{name: "-4", expected: slog.LevelDebug},
+		{name: "+3", expected: slog.Level(3)},
+		{name: " 12 ", expected: LevelFatal},
+		{name: "WARNING", expected: slog.LevelWarn},
+		{name: "err", expected: slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualLevel, err := GetLevelByName(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *actualLevel)
+		})
+	}
+}
+
+func TestGetLevelByName_InvalidOffsets(t *testing.T) {
+	for _, name := range []string{"INFO+", "INFO-", "+", "-", "INFO+X", "BREAKME+1", "INFO++1", "INFO+1.5",
+		"DEBUG-9223372036854775805", "ERROR+9223372036854775800", "99999999999999999999"} {
+		t.Run(name, func(t *testing.T) {
+			actualLevel, err := GetLevelByName(name)
+			assert.Nil(t, actualLevel)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetLevelFromEnv_OffsetValue(t *testing.T) {
+	offsetEnvVar := "OFFSET_LEVEL"
+	require.NoError(t, os.Setenv(offsetEnvVar, "DEBUG-2"))
+
+	actualLevel := GetLevelFromEnv(offsetEnvVar, slog.LevelInfo)
+	assert.Equal(t, slog.LevelDebug-2, actualLevel)
+}
+
+func FuzzGetLevelByName_RoundTrip(f *testing.F) {
+	for _, level := range []int{-8, -5, -4, 0, 1, 2, 4, 8, 12, 16, 100, -100, math.MaxInt, math.MinInt} {
+		f.Add(level)
+	}
+
+	f.Fuzz(func(t *testing.T, n int) {
+		level := slog.Level(n)
+		text, err := level.MarshalText()
+		require.NoError(t, err)
+
+		for _, name := range []string{level.String(), string(text), LevelName(level), strconv.Itoa(n)} {
+			actualLevel, err := GetLevelByName(name)
+			require.NoError(t, err, name)
+			assert.Equal(t, level, *actualLevel, name)
+		}
+	})
+}
+
+func FuzzGetLevelByName_Stable(f *testing.F) {
+	for _, name := range []string{"DEBUG", "info+1", "WARNING-2", "-4", "TRACE", "ERR+100", "BREAKME", "+", ""} {
+		f.Add(name)
+	}
+
+	f.Fuzz(func(t *testing.T, name string) {
+		level, err := GetLevelByName(name)
+		if err != nil {
+			return
+		}
+
+		// A parsed level renders to a name that parses to the same level
+		reparsedLevel, err := GetLevelByName(LevelName(*level))
+		require.NoError(t, err)
+		assert.Equal(t, *level, *reparsedLevel)
+	})
+}
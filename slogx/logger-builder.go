@@ -1,6 +1,7 @@
 package slogx
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -15,6 +16,40 @@ const (
 	FormatJSON Format = 1
 )
 
+// String returns the name of the Format, "text" or "json".
+func (f Format) String() string {
+	switch f {
+	case FormatText:
+		return "text"
+	case FormatJSON:
+		return "json"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, so a Format is marshalled by name.
+func (f Format) MarshalText() ([]byte, error) {
+	if f != FormatText && f != FormatJSON {
+		return nil, fmt.Errorf("invalid format: %d", int(f))
+	}
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so a Format is unmarshalled from "text" or "json",
+// case-insensitive.
+func (f *Format) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "text":
+		*f = FormatText
+	case "json":
+		*f = FormatJSON
+	default:
+		return fmt.Errorf("invalid format: %q", string(text))
+	}
+	return nil
+}
+
 // timeLayoutTokens are the reference substrings that Go's time package recognises as format
 // tokens. A layout must contain at least one to produce meaningful timestamp output.
 // See https://pkg.go.dev/time#Layout for the full reference time.
@@ -32,6 +67,46 @@ var timeLayoutTokens = []string{
 	"Z0700", "Z07:00", // UTC indicator + offset
 }
 
+// FieldError is a validation failure of a LoggerBuilder field, such as an invalid level string.  BuildE returns the
+// FieldErrors of a LoggerBuilder joined with errors.Join.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidLevelKey is returned by BuildE, wrapped in a FieldError for the "levelKey" field, when the LevelFunc set
+// with WithLevelEnvVar or WithLevelFunc returns an invalid level name or panics.  The logger is still built, with the
+// default level, so check for it with errors.Is and treat it as a warning.
+var ErrInvalidLevelKey = errors.New("invalid level key")
+
+// levelKeyError is the error of a LevelFunc that returns an invalid level name or panics.  It matches
+// ErrInvalidLevelKey.
+type levelKeyError struct {
+	err error
+}
+
+func (e *levelKeyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *levelKeyError) Unwrap() []error {
+	return []error{ErrInvalidLevelKey, e.err}
+}
+
+// weakLevelManager is implemented by LevelManagers that can hold a slog.LevelVar weakly, such as the
+// ExtendedLevelManager.
+type weakLevelManager interface {
+	ManageLevelWeakly(levelVar *slog.LevelVar, key string, levelFunc LevelFunc) error
+}
+
 type LoggerBuilder interface {
 	WithContextHandler() LoggerBuilder
 	WithFormat(format Format) LoggerBuilder
@@ -44,18 +119,59 @@ type LoggerBuilder interface {
 	Build() (*slog.Logger, *slog.LevelVar)
 }
 
+// ExtendedLoggerBuilder is a LoggerBuilder with the options added since v1.0, whose methods return an
+// ExtendedLoggerBuilder so that they can be chained.  Invalid configuration is collected as a FieldError for each
+// field, and returned by BuildE.  Build panics instead of returning the errors.
+type ExtendedLoggerBuilder interface {
+	WithContextHandler() ExtendedLoggerBuilder
+	WithContextGroup(group string) ExtendedLoggerBuilder
+	WithTraceContext(traceContextFunc TraceContextFunc) ExtendedLoggerBuilder
+	WithBaggage(baggageFunc BaggageFunc, keys ...string) ExtendedLoggerBuilder
+	WithFormat(format Format) ExtendedLoggerBuilder
+	WithWriter(writer io.Writer) ExtendedLoggerBuilder
+	WithLevel(level slog.Level) ExtendedLoggerBuilder
+	WithLevelString(level string) ExtendedLoggerBuilder
+	WithLevelEnvVar(key string) ExtendedLoggerBuilder
+	WithLevelFunc(key string, levelFunc LevelFunc) ExtendedLoggerBuilder
+	WithTimestampFormat(format string) ExtendedLoggerBuilder
+	WithName(name string) ExtendedLoggerBuilder
+	WithAddSource() ExtendedLoggerBuilder
+	WithLevelManager(levelManager LevelManager) ExtendedLoggerBuilder
+	WithManagedLevel() ExtendedLoggerBuilder
+	FromEnv(prefix string) ExtendedLoggerBuilder
+	Build() (*slog.Logger, *slog.LevelVar)
+	// BuildE returns the logger together with an error matching ErrInvalidLevelKey if only the level variable is
+	// invalid, and no logger for any other error.
+	BuildE() (*slog.Logger, *slog.LevelVar, error)
+	// Close closes the output files opened by the builds of the builder, such as those of FromEnv.
+	io.Closer
+}
+
 type defaultLoggerBuilder struct {
 	writer            io.Writer
 	format            Format
 	level             slog.Level
 	useContextHandler bool
+	contextGroup      string
+	traceContextFunc  TraceContextFunc
+	baggageFunc       BaggageFunc
+	baggageKeys       []string
 	levelKey          string
 	levelFunc         LevelFunc
 	timestampFormat   string
+	name              string
+	levelManager      LevelManager
+	managedLevel      bool
+	addSource         bool
+	outputs           []string
+	outputsField      string
+	files             []*os.File
+	errs              []*FieldError
 }
 
 // NewLoggerBuilder creates a new LoggerBuilder with default values.  The default values are:  LevelInfo, FormatText,
-// useContextHandler=false, levelKey="", levelFunc=nil, writer=os.Stderr. and the slog default for timestamp format
+// useContextHandler=false, levelKey="", levelFunc=nil, writer=os.Stderr, name="", levelManager=nil,
+// managedLevel=false, addSource=false and the slog default for timestamp format
 func NewLoggerBuilder() LoggerBuilder {
 	return &defaultLoggerBuilder{
 		level:             slog.LevelInfo,
@@ -65,15 +181,104 @@ func NewLoggerBuilder() LoggerBuilder {
 		levelFunc:         nil,
 		writer:            os.Stderr,
 		timestampFormat:   "",
+		name:              "",
+		levelManager:      nil,
+		managedLevel:      false,
+		addSource:         false,
 	}
 }
 
+// extendedLoggerBuilder is the ExtendedLoggerBuilder, which shares the configuration and Build of the
+// defaultLoggerBuilder.
+type extendedLoggerBuilder struct {
+	*defaultLoggerBuilder
+}
+
+// NewExtendedLoggerBuilder creates a new ExtendedLoggerBuilder with the same default values as NewLoggerBuilder.
+func NewExtendedLoggerBuilder() ExtendedLoggerBuilder {
+	return &extendedLoggerBuilder{defaultLoggerBuilder: NewLoggerBuilder().(*defaultLoggerBuilder)}
+}
+
+// WithContextHandler enables the ContextHandler for the logger.
+func (lb *extendedLoggerBuilder) WithContextHandler() ExtendedLoggerBuilder {
+	lb.defaultLoggerBuilder.WithContextHandler()
+	return lb
+}
+
+// WithFormat sets the Format for the logger.
+func (lb *extendedLoggerBuilder) WithFormat(format Format) ExtendedLoggerBuilder {
+	lb.defaultLoggerBuilder.WithFormat(format)
+	return lb
+}
+
+// WithWriter sets the io.Writer for the logger.
+func (lb *extendedLoggerBuilder) WithWriter(writer io.Writer) ExtendedLoggerBuilder {
+	lb.defaultLoggerBuilder.WithWriter(writer)
+	return lb
+}
+
+// WithLevel sets the slog.Level for the logger.
+func (lb *extendedLoggerBuilder) WithLevel(level slog.Level) ExtendedLoggerBuilder {
+	lb.defaultLoggerBuilder.WithLevel(level)
+	return lb
+}
+
+// WithLevelString sets the slog.Level for the logger with a string.  If the string is not a valid level name, BuildE
+// returns an error for the "level" field.
+func (lb *extendedLoggerBuilder) WithLevelString(level string) ExtendedLoggerBuilder {
+	lb.defaultLoggerBuilder.WithLevelString(level)
+	return lb
+}
+
+// WithLevelEnvVar sets the environment variable key to use to get the level.
+func (lb *extendedLoggerBuilder) WithLevelEnvVar(key string) ExtendedLoggerBuilder {
+	lb.defaultLoggerBuilder.WithLevelEnvVar(key)
+	return lb
+}
+
+// WithLevelFunc sets the level variable key and function to use to get the level name.
+func (lb *extendedLoggerBuilder) WithLevelFunc(key string, levelFunc LevelFunc) ExtendedLoggerBuilder {
+	lb.defaultLoggerBuilder.WithLevelFunc(key, levelFunc)
+	return lb
+}
+
+// WithTimestampFormat sets the timestamp format of the logs.  If the format contains no recognised Go time layout
+// tokens, BuildE returns an error for the "timestampFormat" field.
+func (lb *extendedLoggerBuilder) WithTimestampFormat(format string) ExtendedLoggerBuilder {
+	lb.defaultLoggerBuilder.WithTimestampFormat(format)
+	return lb
+}
+
 // WithContextHandler enables the ContextHandler for the logger.
 func (lb *defaultLoggerBuilder) WithContextHandler() LoggerBuilder {
 	lb.useContextHandler = true
 	return lb
 }
 
+// WithContextGroup enables the ContextHandler for the logger and adds the context attrs under the named group.
+func (lb *extendedLoggerBuilder) WithContextGroup(group string) ExtendedLoggerBuilder {
+	lb.useContextHandler = true
+	lb.contextGroup = group
+	return lb
+}
+
+// WithTraceContext enables the ContextHandler for the logger and adds the trace_id, span_id and trace_flags of the
+// active span, as returned by the TraceContextFunc, to each record.
+func (lb *extendedLoggerBuilder) WithTraceContext(traceContextFunc TraceContextFunc) ExtendedLoggerBuilder {
+	lb.useContextHandler = true
+	lb.traceContextFunc = traceContextFunc
+	return lb
+}
+
+// WithBaggage enables the ContextHandler for the logger and adds the W3C Baggage members with the provided keys, as
+// returned by the BaggageFunc, to the context attrs of each record.
+func (lb *extendedLoggerBuilder) WithBaggage(baggageFunc BaggageFunc, keys ...string) ExtendedLoggerBuilder {
+	lb.useContextHandler = true
+	lb.baggageFunc = baggageFunc
+	lb.baggageKeys = keys
+	return lb
+}
+
 // WithFormat sets the Format for the logger.
 func (lb *defaultLoggerBuilder) WithFormat(format Format) LoggerBuilder {
 	lb.format = format
@@ -83,21 +288,49 @@ func (lb *defaultLoggerBuilder) WithFormat(format Format) LoggerBuilder {
 // WithWriter sets the io.Writer for the logger.
 func (lb *defaultLoggerBuilder) WithWriter(writer io.Writer) LoggerBuilder {
 	lb.writer = writer
+	lb.outputs = nil
 	return lb
 }
 
+// withOutputs sets the outputs for the logger, replacing the io.Writer.  Each output is "stdout", "stderr" or the path
+// of a file to append to.  The outputs are opened when the logger is built, and an error opening them is reported for
+// the provided field.
+func (lb *defaultLoggerBuilder) withOutputs(field string, outputs []string) {
+	lb.outputs = outputs
+	lb.outputsField = field
+}
+
+// closeOutputs closes the output files opened by the builds of the builder.
+func (lb *defaultLoggerBuilder) closeOutputs() error {
+	err := closeFiles(lb.files)
+	lb.files = nil
+	return err
+}
+
+// Close closes the output files opened by the builds of the builder, from <PREFIX>_OUTPUT with FromEnv or from the
+// outputs of a Config, and returns their errors joined with errors.Join.  Loggers built by the builder must not be
+// used after Close.  Close does nothing if no files were opened, so it can always be deferred.
+func (lb *extendedLoggerBuilder) Close() error {
+	return lb.closeOutputs()
+}
+
 // WithLevel sets the slog.Level for the logger.
 func (lb *defaultLoggerBuilder) WithLevel(level slog.Level) LoggerBuilder {
+	lb.setFieldError("level", nil)
 	lb.level = level
 	return lb
 }
 
-// WithLevelString sets the slog.Level for the logger with a string.
+// WithLevelString sets the slog.Level for the logger with a string.  If the string is not a valid level name, BuildE
+// returns an error for the "level" field.
 func (lb *defaultLoggerBuilder) WithLevelString(level string) LoggerBuilder {
 	lvlPtr, err := GetLevelByName(level)
 	if err != nil {
-		panic(fmt.Sprintf("invalid log level, %s is not a valid log level from the slog package", level))
+		lb.setFieldError("level",
+			fmt.Errorf("invalid log level, %s is not a valid log level from the slog package", level))
+		return lb
 	}
+	lb.setFieldError("level", nil)
 	lb.level = *lvlPtr
 	return lb
 }
@@ -117,68 +350,316 @@ func (lb *defaultLoggerBuilder) WithLevelFunc(key string, levelFunc LevelFunc) L
 
 // WithTimestampFormat sets the timestamp format of the logs. The format must contain at least
 // one recognised Go time layout token (e.g. "2006", "Jan", "15") so that it produces a
-// meaningful timestamp. See https://pkg.go.dev/time#Layout for the reference time.
+// meaningful timestamp. See https://pkg.go.dev/time#Layout for the reference time.  If it does not, BuildE returns an
+// error for the "timestampFormat" field.
 func (lb *defaultLoggerBuilder) WithTimestampFormat(format string) LoggerBuilder {
+	if err := validateTimestampFormat(format); err != nil {
+		lb.setFieldError("timestampFormat", err)
+		return lb
+	}
+	lb.setFieldError("timestampFormat", nil)
+	lb.timestampFormat = format
+	return lb
+}
+
+// validateTimestampFormat returns an error if the format contains no recognised Go time layout tokens.
+func validateTimestampFormat(format string) error {
 	for _, token := range timeLayoutTokens {
 		if strings.Contains(format, token) {
-			lb.timestampFormat = format
-			return lb
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid timestamp format: %q contains no recognised Go time layout tokens", format)
+}
+
+// WithAddSource adds the source file and line of the logging call to each record.
+func (lb *extendedLoggerBuilder) WithAddSource() ExtendedLoggerBuilder {
+	lb.addSource = true
+	return lb
+}
+
+// WithName registers the logger with the LoggerRegistry under the provided dot separated name, such as
+// "payments.ledger", so that its level can be controlled with LoggerRegistry.SetLevel on the name or an ancestor.  If
+// the name is not valid, BuildE returns an error for the "name" field.
+func (lb *extendedLoggerBuilder) WithName(name string) ExtendedLoggerBuilder {
+	if name == "" {
+		lb.setFieldError("name", errors.New("invalid logger name: name is required"))
+		return lb
+	}
+	if err := validateLoggerName(name); err != nil {
+		lb.setFieldError("name", err)
+		return lb
+	}
+	lb.setFieldError("name", nil)
+	lb.name = name
+	return lb
+}
+
+// WithLevelManager enrolls the slog.LevelVar of the logger in the provided LevelManager when the logger is built, with
+// the key and LevelFunc set with WithLevelEnvVar or WithLevelFunc, so that LevelManager.UpdateLevels updates the level
+// of the logger.  If the LevelManager implements ManageLevelWeakly, as an ExtendedLevelManager does, the slog.LevelVar
+// is held weakly, so the enrollment is removed once the logger and its slog.LevelVar are garbage collected.  BuildE
+// returns an error for the "levelManager" field if no level key is set.
+func (lb *extendedLoggerBuilder) WithLevelManager(levelManager LevelManager) ExtendedLoggerBuilder {
+	lb.levelManager = levelManager
+	return lb
+}
+
+// WithManagedLevel enrolls the slog.LevelVar of the logger in the LevelManager set with WithLevelManager, or in the
+// default LevelManager returned by GetExtendedLevelManager, when the logger is built.  The slog.LevelVar is enrolled
+// with the key and LevelFunc set with WithLevelEnvVar or WithLevelFunc, so that LevelManager.UpdateLevels updates the
+// level of the logger.  The slog.LevelVar is held weakly, as with WithLevelManager.  BuildE returns an error for the
+// "levelManager" field if no level key is set.
+func (lb *extendedLoggerBuilder) WithManagedLevel() ExtendedLoggerBuilder {
+	lb.managedLevel = true
+	return lb
+}
+
+// FromEnv configures the logger from the environment variables with the provided prefix, such as LOG_FORMAT=json for
+// the prefix "LOG".  The variables are <PREFIX>_FORMAT, <PREFIX>_OUTPUT (stdout, stderr or a file path, or a comma
+// separated list of them), <PREFIX>_LEVEL, <PREFIX>_LEVEL_KEY, <PREFIX>_TIME_FORMAT, <PREFIX>_CONTEXT_HANDLER and
+// <PREFIX>_ADD_SOURCE.  Only the variables that are set are applied, overriding earlier builder calls.  BuildE returns
+// a FieldError, named by the variable, for every invalid value.  Output files are opened when the logger is built, and
+// are closed if the build fails or when Close is called.
+func (lb *extendedLoggerBuilder) FromEnv(prefix string) ExtendedLoggerBuilder {
+	// Replace the errors of an earlier call with the same prefix
+	errs := lb.errs[:0]
+	for _, fieldErr := range lb.errs {
+		if !strings.HasPrefix(fieldErr.Field, prefix+"_") {
+			errs = append(errs, fieldErr)
+		}
+	}
+	lb.errs = errs
+
+	settings, fieldErrs := readEnvSettings(prefix)
+	lb.errs = append(lb.errs, fieldErrs...)
+	if settings.format != nil {
+		lb.WithFormat(*settings.format)
+	}
+	if settings.outputs != nil {
+		lb.withOutputs(prefix+"_OUTPUT", settings.outputs)
+	}
+	if settings.level != nil {
+		lb.WithLevelString(*settings.level)
+	}
+	if settings.levelKey != nil {
+		lb.WithLevelEnvVar(*settings.levelKey)
+	}
+	if settings.timestampFormat != nil {
+		lb.WithTimestampFormat(*settings.timestampFormat)
+	}
+	if settings.contextHandler != nil {
+		lb.useContextHandler = *settings.contextHandler
+	}
+	if settings.addSource != nil {
+		lb.addSource = *settings.addSource
+	}
+	return lb
+}
+
+// setFieldError replaces the error recorded for a field.  If err is nil, the error is removed, so that the last call
+// to a With method for the field decides whether it is valid.
+func (lb *defaultLoggerBuilder) setFieldError(field string, err error) {
+	errs := lb.errs[:0]
+	for _, fieldErr := range lb.errs {
+		if fieldErr.Field != field {
+			errs = append(errs, fieldErr)
 		}
 	}
-	panic(fmt.Sprintf("invalid timestamp format: %q contains no recognised Go time layout tokens", format))
+	if err != nil {
+		errs = append(errs, &FieldError{Field: field, Err: err})
+	}
+	lb.errs = errs
 }
 
 // Build creates a new slog.Logger with the provided configuration. A slog.LevelVar to control the
-// logger level is also returned.
+// logger level is also returned.  Build panics if the configuration is not valid.  Use BuildE to handle the errors.
+// If the LevelFunc set with WithLevelEnvVar or WithLevelFunc returns an invalid level name or panics, a warning is
+// logged and the default level is used.
 func (lb *defaultLoggerBuilder) Build() (*slog.Logger, *slog.LevelVar) {
+	logger, levelVar, levelErr, err := lb.build()
+	if err != nil {
+		panic(err.Error())
+	}
+	if levelErr != nil {
+		slog.Default().Warn("Key is an invalid logging level name.",
+			slog.String("key", lb.levelKey),
+			slog.String("error", levelErr.Err.Error()))
+	}
+	return logger, levelVar
+}
+
+// BuildE creates a new slog.Logger with the provided configuration. A slog.LevelVar to control the
+// logger level is also returned.  If the configuration is not valid, a FieldError is returned for every invalid
+// field, joined with errors.Join, and no logger is built.  If the LevelFunc set with WithLevelEnvVar or WithLevelFunc
+// returns an invalid level name or panics, the logger is built with the default level and a FieldError for the
+// "levelKey" field that matches ErrInvalidLevelKey is returned with it.
+func (lb *extendedLoggerBuilder) BuildE() (*slog.Logger, *slog.LevelVar, error) {
+	logger, levelVar, levelErr, err := lb.build()
+	if err != nil {
+		return nil, nil, err
+	}
+	if levelErr != nil {
+		return logger, levelVar, levelErr
+	}
+	return logger, levelVar, nil
+}
+
+// build creates a new slog.Logger with the provided configuration.  err is not nil if the configuration is not valid,
+// and levelErr is not nil if the level could not be read from the LevelFunc, in which case the default level is used.
+func (lb *defaultLoggerBuilder) build() (logger *slog.Logger, levelVar *slog.LevelVar, levelErr *FieldError,
+	err error) {
+	errs := make([]error, 0, len(lb.errs))
+	for _, fieldErr := range lb.errs {
+		errs = append(errs, fieldErr)
+	}
+	if lb.writer == nil && lb.outputs == nil {
+		errs = append(errs, &FieldError{Field: "writer", Err: errors.New("writer is required")})
+	}
+	if lb.format != FormatText && lb.format != FormatJSON {
+		errs = append(errs, &FieldError{Field: "format", Err: fmt.Errorf("invalid format: %d", lb.format)})
+	}
 
 	// Set the default level
-	levelVar := new(slog.LevelVar)
+	levelVar = new(slog.LevelVar)
 	levelVar.Set(lb.level)
 
 	// If a level variable key is provided and a level function is provided, set the level from the level function
 	// Otherwise, try to set the level from the environment
+	levelFunc := lb.levelFunc
+	if levelFunc == nil {
+		levelFunc = getEnvLevelFunc()
+	}
 	if lb.levelKey != "" {
-		if lb.levelFunc != nil {
-			levelVar.Set(GetLevelFromFunc(lb.levelKey, lb.levelFunc, lb.level))
-		} else {
-			levelVar.Set(GetLevelFromFunc(lb.levelKey, getEnvLevelFunc(), lb.level))
+		level, err := levelFromFunc(lb.levelKey, levelFunc)
+		if err != nil {
+			levelErr = &FieldError{Field: "levelKey", Err: &levelKeyError{err: err}}
+		} else if level != nil {
+			levelVar.Set(*level)
 		}
 	}
 
-	// Create the handler
-	handlerOpts := &slog.HandlerOptions{
-		Level: levelVar,
+	// Enroll the level in the LevelManager with the same key and function
+	levelManager := lb.levelManager
+	if levelManager == nil && lb.managedLevel {
+		levelManager = GetExtendedLevelManager()
+	}
+	if levelManager != nil && lb.levelKey == "" {
+		errs = append(errs, &FieldError{
+			Field: "levelManager",
+			Err:   errors.New("a level key is required to manage the level, set it with WithLevelEnvVar or WithLevelFunc"),
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, nil, errors.Join(errs...)
+	}
+
+	// Open the outputs, closing them if the build fails
+	writer := lb.writer
+	var files []*os.File
+	if lb.outputs != nil {
+		var err error
+		writer, files, err = openOutputs(lb.outputs)
+		if err != nil {
+			return nil, nil, nil, &FieldError{Field: lb.outputsField, Err: err}
+		}
+	}
+
+	// Register the level with the LoggerRegistry, which applies any level set for the name or its ancestors
+	if lb.name != "" {
+		if err := GetLoggerRegistry().Register(lb.name, levelVar); err != nil {
+			_ = closeFiles(files)
+			return nil, nil, nil, &FieldError{Field: "name", Err: err}
+		}
 	}
 
-	// Only install ReplaceAttr when a custom timestamp format is configured. This keeps
-	// the per-attribute callback off the hot path entirely for the default format.
-	if lb.timestampFormat != "" {
-		format := lb.timestampFormat
-		handlerOpts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
-			if a.Key == slog.TimeKey && len(groups) == 0 {
-				return slog.String(slog.TimeKey, a.Value.Time().Format(format))
+	if levelManager != nil {
+		var err error
+		// The logger references the slog.LevelVar, so a weak enrollment lasts as long as the logger
+		if weakManager, ok := levelManager.(weakLevelManager); ok {
+			err = weakManager.ManageLevelWeakly(levelVar, lb.levelKey, lb.levelFunc)
+		} else if lb.levelFunc != nil {
+			err = levelManager.ManageLevelFromFunc(levelVar, lb.levelKey, lb.levelFunc)
+		} else {
+			err = levelManager.ManageLevelFromEnv(levelVar, lb.levelKey)
+		}
+		if err != nil {
+			if lb.name != "" {
+				GetLoggerRegistry().Unregister(levelVar)
 			}
-			return a
+			_ = closeFiles(files)
+			return nil, nil, nil, &FieldError{Field: "levelManager", Err: err}
 		}
 	}
+	lb.files = append(lb.files, files...)
 
+	// If the writer buffers output, serialize its writes and flushes
+	var sharedWriter *syncWriter
+	if _, buffered := writer.(Flusher); buffered {
+		sharedWriter = &syncWriter{writer: writer}
+		writer = sharedWriter
+	}
+
+	// Create the handler.  The ReplaceAttr function returns early for every attr except the top level time and level,
+	// so it stays cheap on the hot path.
+	handlerOpts := &slog.HandlerOptions{
+		AddSource:   lb.addSource,
+		Level:       levelVar,
+		ReplaceAttr: newReplaceAttr(lb.timestampFormat),
+	}
 	var handler slog.Handler
 	if lb.format == FormatJSON {
-		handler = slog.NewJSONHandler(lb.writer, handlerOpts)
+		handler = slog.NewJSONHandler(writer, handlerOpts)
 	} else {
-		handler = slog.NewTextHandler(lb.writer, handlerOpts)
+		handler = slog.NewTextHandler(writer, handlerOpts)
+	}
+
+	// If the writer buffers output, allow the Fatal and Panic functions to flush it
+	if sharedWriter != nil {
+		handler = &flushHandler{Handler: handler, flusher: sharedWriter}
 	}
 
 	// If the context handler is enabled, wrap the handler with a ContextHandler
 	if lb.useContextHandler {
-		handler = NewContextHandler(handler)
+		handler = NewContextHandlerWithOptions(handler, &ContextHandlerOptions{
+			Group:            lb.contextGroup,
+			TraceContextFunc: lb.traceContextFunc,
+			BaggageFunc:      lb.baggageFunc,
+			BaggageKeys:      lb.baggageKeys,
+		})
 	}
 
 	// Create the logger
-	logger := slog.New(handler)
+	logger = slog.New(handler)
 
 	slog.Default()
 
-	return logger, levelVar
+	return logger, levelVar, levelErr, nil
+}
+
+// newReplaceAttr returns a slog.HandlerOptions.ReplaceAttr function that renders levels other than the slog levels
+// by their registered names and, if timestampFormat is not empty, formats the timestamp with it.  Other attrs are
+// returned unchanged.
+func newReplaceAttr(timestampFormat string) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) != 0 || (a.Key != slog.TimeKey && a.Key != slog.LevelKey) {
+			return a
+		}
+		switch a.Key {
+		case slog.TimeKey:
+			if timestampFormat != "" && a.Value.Kind() == slog.KindTime {
+				return slog.String(slog.TimeKey, a.Value.Time().Format(timestampFormat))
+			}
+		case slog.LevelKey:
+			if level, ok := a.Value.Any().(slog.Level); ok {
+				switch level {
+				case slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError:
+				default:
+					return slog.String(slog.LevelKey, LevelName(level))
+				}
+			}
+		}
+		return a
+	}
 }
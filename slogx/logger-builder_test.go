@@ -2,13 +2,18 @@ package slogx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
+	"weak"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -67,7 +72,7 @@ func TestWithLevelString(t *testing.T) {
 
 func TestWithLevelStringInvalid(t *testing.T) {
 	expectPanic(t, func() {
-		NewLoggerBuilder().WithLevelString("invalid")
+		NewLoggerBuilder().WithLevelString("invalid").Build()
 	})
 }
 
@@ -104,7 +109,7 @@ func TestWithTimestampFormatUnset(t *testing.T) {
 
 func TestWithTimestampFormatInvalid(t *testing.T) {
 	expectPanic(t, func() {
-		NewLoggerBuilder().WithTimestampFormat("invalid")
+		NewLoggerBuilder().WithTimestampFormat("invalid").Build()
 	})
 }
 
@@ -209,3 +214,459 @@ func TestBuild_WithTimestampFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestWithContextGroup(t *testing.T) {
+	builder := NewExtendedLoggerBuilder().WithContextGroup("ctx").(*extendedLoggerBuilder)
+	assert.True(t, builder.useContextHandler)
+	assert.Equal(t, "ctx", builder.contextGroup)
+}
+
+func TestWithTraceContext(t *testing.T) {
+	builder := NewExtendedLoggerBuilder().WithTraceContext(fakeTraceContextFunc).(*extendedLoggerBuilder)
+	assert.True(t, builder.useContextHandler)
+	assert.NotNil(t, builder.traceContextFunc)
+}
+
+func TestWithBaggage(t *testing.T) {
+	builder := NewExtendedLoggerBuilder().WithBaggage(fakeBaggageFunc, "tenant", "flag.beta").(*extendedLoggerBuilder)
+	assert.True(t, builder.useContextHandler)
+	assert.NotNil(t, builder.baggageFunc)
+	assert.Equal(t, []string{"tenant", "flag.beta"}, builder.baggageKeys)
+}
+
+func TestWithLevelString_RegisteredLevel(t *testing.T) {
+	builder := NewLoggerBuilder().WithLevelString("notice").(*defaultLoggerBuilder)
+	assert.Equal(t, LevelNotice, builder.level)
+}
+
+func TestBuild_RendersRegisteredLevelNames(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := NewLoggerBuilder().
+		WithWriter(&buf).
+		WithLevel(LevelTrace).
+		Build()
+
+	logger.Log(context.Background(), LevelTrace, "A")
+	logger.Log(context.Background(), LevelNotice, "B")
+	logger.Log(context.Background(), LevelFatal, "C")
+	logger.Log(context.Background(), slog.LevelDebug+1, "D")
+	logger.WithGroup("g").Info("E", slog.String("level", "not a level"))
+
+	output := buf.String()
+	assert.Contains(t, output, `level=TRACE msg=A`)
+	assert.Contains(t, output, `level=NOTICE msg=B`)
+	assert.Contains(t, output, `level=FATAL msg=C`)
+	assert.Contains(t, output, `level=DEBUG+1 msg=D`)
+	assert.Contains(t, output, `level=INFO msg=E g.level="not a level"`)
+}
+
+func TestBuild_Handler(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := NewLoggerBuilder().WithWriter(&buf).WithFormat(FormatJSON).WithLevel(LevelTrace).Build()
+	assert.IsType(t, &slog.JSONHandler{}, logger.Handler())
+
+	logger = logger.With("a", 1).WithGroup("g")
+	logger.Info("A", "b", 2)
+	logger.Log(context.Background(), LevelTrace, "B", "b", 3)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"level":"INFO","msg":"A","a":1,"g":{"b":2}`)
+	assert.Contains(t, lines[1], `"level":"TRACE","msg":"B","a":1,"g":{"b":3}`)
+
+	logger, _ = NewLoggerBuilder().WithWriter(&buf).WithTimestampFormat(time.Kitchen).Build()
+	assert.IsType(t, &slog.TextHandler{}, logger.Handler())
+}
+
+func TestWithName(t *testing.T) {
+	builder := NewExtendedLoggerBuilder().WithName("payments.ledger").(*extendedLoggerBuilder)
+	assert.Equal(t, "payments.ledger", builder.name)
+}
+
+func TestWithNameInvalid(t *testing.T) {
+	expectPanic(t, func() {
+		NewExtendedLoggerBuilder().WithName("").Build()
+	})
+	expectPanic(t, func() {
+		NewExtendedLoggerBuilder().WithName("payments..ledger").Build()
+	})
+}
+
+func TestBuild_WithLevelManager(t *testing.T) {
+	levelManager := NewLevelManager()
+
+	levelName := "WARN"
+	_, levelVar1 := NewExtendedLoggerBuilder().
+		WithLevelFunc("BUILDER_FUNC_LEVEL", func(key string) string {
+			return levelName
+		}).
+		WithLevelManager(levelManager).
+		Build()
+	assert.Equal(t, slog.LevelWarn, levelVar1.Level())
+
+	t.Setenv("BUILDER_ENV_LEVEL", "ERROR")
+	_, levelVar2 := NewExtendedLoggerBuilder().
+		WithLevelEnvVar("BUILDER_ENV_LEVEL").
+		WithLevelManager(levelManager).
+		Build()
+	assert.Equal(t, slog.LevelError, levelVar2.Level())
+
+	assert.Equal(t, []ManagedLevel{
+		{Key: "BUILDER_ENV_LEVEL", Level: slog.LevelError, Source: LevelSourceEnv},
+		{Key: "BUILDER_FUNC_LEVEL", Level: slog.LevelWarn, Source: LevelSourceFunc},
+	}, levelManager.Managed())
+
+	levelName = "DEBUG"
+	t.Setenv("BUILDER_ENV_LEVEL", "TRACE")
+	levelManager.UpdateLevels()
+	assert.Equal(t, slog.LevelDebug, levelVar1.Level())
+	assert.Equal(t, LevelTrace, levelVar2.Level())
+}
+
+func TestBuild_WithLevelManager_HeldWeakly(t *testing.T) {
+	levelManager := newLevelManager()
+	_, levelVar := NewExtendedLoggerBuilder().
+		WithLevelEnvVar("BUILDER_WEAK_LEVEL").
+		WithLevelManager(levelManager).
+		Build()
+
+	// The enrollment does not keep the slog.LevelVar of a discarded logger alive
+	_, heldStrongly := levelManager.levelVarMap.Load(levelVar)
+	assert.False(t, heldStrongly)
+	_, heldWeakly := levelManager.levelVarMap.Load(weak.Make(levelVar))
+	assert.True(t, heldWeakly)
+}
+
+func TestBuild_WithLevelManager_HeldStrongly(t *testing.T) {
+	// A LevelManager that only implements the v1 methods enrolls the slog.LevelVar with ManageLevelFromEnv
+	levelManager := newLevelManager()
+	_, levelVar := NewExtendedLoggerBuilder().
+		WithLevelEnvVar("BUILDER_STRONG_LEVEL").
+		WithLevelManager(struct{ LevelManager }{levelManager}).
+		Build()
+
+	_, heldStrongly := levelManager.levelVarMap.Load(levelVar)
+	assert.True(t, heldStrongly)
+}
+
+func TestBuild_WithLevelManager_NoLevelKey(t *testing.T) {
+	expectPanic(t, func() {
+		NewExtendedLoggerBuilder().WithLevelManager(NewLevelManager()).Build()
+	})
+}
+
+func TestBuild_WithManagedLevel(t *testing.T) {
+	t.Setenv("BUILDER_MANAGED_LEVEL", "WARN")
+	_, levelVar := NewExtendedLoggerBuilder().
+		WithLevelEnvVar("BUILDER_MANAGED_LEVEL").
+		WithManagedLevel().
+		Build()
+	t.Cleanup(func() {
+		GetExtendedLevelManager().Unmanage(levelVar)
+	})
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+
+	// The level is enrolled in the default LevelManager
+	t.Setenv("BUILDER_MANAGED_LEVEL", "DEBUG")
+	GetLevelManager().UpdateLevels()
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+}
+
+func TestBuild_WithManagedLevel_WithLevelManager(t *testing.T) {
+	levelManager := NewLevelManager()
+	_, levelVar := NewExtendedLoggerBuilder().
+		WithLevelFunc("BUILDER_MANAGED_FUNC_LEVEL", func(key string) string {
+			return "ERROR"
+		}).
+		WithManagedLevel().
+		WithLevelManager(levelManager).
+		Build()
+
+	// The level is enrolled in the LevelManager set with WithLevelManager, rather than the default LevelManager
+	assert.Len(t, levelManager.Managed(), 1)
+	assert.True(t, levelManager.Unmanage(levelVar))
+	assert.False(t, GetExtendedLevelManager().Unmanage(levelVar))
+}
+
+func TestBuild_WithManagedLevel_NoLevelKey(t *testing.T) {
+	expectPanic(t, func() {
+		NewExtendedLoggerBuilder().WithManagedLevel().Build()
+	})
+}
+
+func TestBuildE(t *testing.T) {
+	logger, levelVar, err := NewExtendedLoggerBuilder().
+		WithWriter(&bytes.Buffer{}).
+		WithLevelString("debug").
+		WithTimestampFormat(time.RFC3339).
+		BuildE()
+	require.NoError(t, err)
+	assert.NotNil(t, logger)
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+}
+
+func TestBuildE_Errors(t *testing.T) {
+	logger, levelVar, err := NewExtendedLoggerBuilder().
+		WithWriter(nil).
+		WithFormat(Format(7)).
+		WithLevelString("invalid").
+		WithTimestampFormat("invalid").
+		WithName("payments..ledger").
+		BuildE()
+	assert.Nil(t, logger)
+	assert.Nil(t, levelVar)
+	require.Error(t, err)
+
+	var fields []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fieldErr *FieldError
+		require.ErrorAs(t, e, &fieldErr)
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.Equal(t, []string{"level", "timestampFormat", "name", "writer", "format"}, fields)
+	assert.ErrorContains(t, err, "level: invalid log level, invalid is not a valid log level from the slog package")
+	assert.ErrorContains(t, err, `timestampFormat: invalid timestamp format: "invalid"`)
+	assert.ErrorContains(t, err, "name: invalid logger name")
+	assert.ErrorContains(t, err, "writer: writer is required")
+	assert.ErrorContains(t, err, "format: invalid format: 7")
+}
+
+func TestBuildE_InvalidLevelName(t *testing.T) {
+	t.Setenv("BUILDE_LEVEL", "DEBUGG")
+	logger, levelVar, err := NewExtendedLoggerBuilder().
+		WithWriter(&bytes.Buffer{}).
+		WithLevel(slog.LevelWarn).
+		WithLevelEnvVar("BUILDE_LEVEL").
+		BuildE()
+	assert.EqualError(t, err, "levelKey: key BUILDE_LEVEL: invalid level name: DEBUGG")
+	assert.ErrorIs(t, err, ErrInvalidLevelKey)
+
+	// The logger is built with the default level
+	require.NotNil(t, logger)
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+}
+
+func TestBuild_InvalidLevelName(t *testing.T) {
+	t.Setenv("BUILD_LEVEL", "DEBUGG")
+	var warnings bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&warnings, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	logger, levelVar := NewLoggerBuilder().
+		WithWriter(&bytes.Buffer{}).
+		WithLevel(slog.LevelWarn).
+		WithLevelEnvVar("BUILD_LEVEL").
+		Build()
+	require.NotNil(t, logger)
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+	assert.Contains(t, warnings.String(), "Key is an invalid logging level name.")
+	assert.Contains(t, warnings.String(), "key=BUILD_LEVEL")
+}
+
+func TestBuildE_LastCallWins(t *testing.T) {
+	_, levelVar, err := NewExtendedLoggerBuilder().
+		WithLevelString("invalid").
+		WithLevelString("warn").
+		WithTimestampFormat("invalid").
+		WithTimestampFormat(time.Kitchen).
+		WithName("").
+		WithName("buildetest").
+		BuildE()
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+
+	_, _, err = NewExtendedLoggerBuilder().
+		WithLevelString("invalid").
+		WithLevel(slog.LevelDebug).
+		BuildE()
+	assert.NoError(t, err)
+}
+
+func TestBuildE_PanickingLevelFunc(t *testing.T) {
+	logger, _, err := NewExtendedLoggerBuilder().
+		WithLevelFunc("BUILDE_LEVEL", func(key string) string {
+			panic("parameter store unavailable")
+		}).
+		BuildE()
+	assert.EqualError(t, err, "levelKey: levelFunc for key BUILDE_LEVEL panicked: parameter store unavailable")
+	assert.NotNil(t, logger)
+}
+
+func TestBuildE_ManagedLevelWithoutKey(t *testing.T) {
+	levelManager := NewLevelManager()
+	_, _, err := NewExtendedLoggerBuilder().
+		WithLevelManager(levelManager).
+		BuildE()
+	var fieldErr *FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "levelManager", fieldErr.Field)
+	assert.Empty(t, levelManager.Managed())
+}
+
+func TestBuild_WithAddSource(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := NewExtendedLoggerBuilder().WithWriter(&buf).WithAddSource().Build()
+	logger.Info("with source")
+	assert.Contains(t, buf.String(), "source=")
+	assert.Contains(t, buf.String(), "logger-builder_test.go:")
+}
+
+func TestBuild_FromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv("FROMENV_LOG_FORMAT", "json")
+	t.Setenv("FROMENV_LOG_OUTPUT", path)
+	t.Setenv("FROMENV_LOG_LEVEL", "debug")
+	t.Setenv("FROMENV_LOG_TIME_FORMAT", time.Kitchen)
+	t.Setenv("FROMENV_LOG_ADD_SOURCE", "true")
+
+	logger, levelVar, err := NewExtendedLoggerBuilder().
+		WithLevel(slog.LevelError).
+		FromEnv("FROMENV_LOG").
+		BuildE()
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+
+	logger.Debug("Hello.")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, "Hello.", entry["msg"])
+	assert.Contains(t, entry, slog.SourceKey)
+	_, err = time.Parse(time.Kitchen, entry[slog.TimeKey].(string))
+	assert.NoError(t, err)
+}
+
+func TestBuild_FromEnv_Unset(t *testing.T) {
+	var buf bytes.Buffer
+	logger, levelVar, err := NewExtendedLoggerBuilder().
+		WithWriter(&buf).
+		WithFormat(FormatJSON).
+		WithLevel(slog.LevelWarn).
+		FromEnv("FROMENV_UNSET").
+		BuildE()
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+
+	logger.Warn("Hello.")
+	assert.True(t, json.Valid(buf.Bytes()))
+}
+
+func TestBuild_FromEnv_LevelKey(t *testing.T) {
+	t.Setenv("FROMENV_LOG_LEVEL_KEY", "FROMENV_LEVEL")
+	t.Setenv("FROMENV_LEVEL", "error")
+
+	_, levelVar, err := NewExtendedLoggerBuilder().
+		WithWriter(&bytes.Buffer{}).
+		FromEnv("FROMENV_LOG").
+		BuildE()
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelError, levelVar.Level())
+}
+
+func TestBuild_FromEnv_Errors(t *testing.T) {
+	t.Setenv("FROMENV_LOG_FORMAT", "xml")
+	t.Setenv("FROMENV_LOG_LEVEL", "LOUD")
+	t.Setenv("FROMENV_LOG_TIME_FORMAT", "invalid")
+	t.Setenv("FROMENV_LOG_ADD_SOURCE", "maybe")
+
+	logger, _, err := NewExtendedLoggerBuilder().
+		FromEnv("FROMENV_LOG").
+		BuildE()
+	assert.Nil(t, logger)
+	require.Error(t, err)
+
+	var fields []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fieldErr *FieldError
+		require.ErrorAs(t, e, &fieldErr)
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.Equal(t, []string{
+		"FROMENV_LOG_FORMAT", "FROMENV_LOG_LEVEL", "FROMENV_LOG_TIME_FORMAT", "FROMENV_LOG_ADD_SOURCE",
+	}, fields)
+	assert.ErrorContains(t, err, `FROMENV_LOG_FORMAT: invalid format: "xml"`)
+	assert.ErrorContains(t, err, "FROMENV_LOG_LEVEL: invalid level name: LOUD")
+	assert.ErrorContains(t, err, `FROMENV_LOG_ADD_SOURCE: invalid boolean: "maybe"`)
+}
+
+func TestBuild_FromEnv_Output(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("FROMENV_LOG_OUTPUT", filepath.Join(dir, "app.log"))
+	lb := NewExtendedLoggerBuilder().FromEnv("FROMENV_LOG").FromEnv("FROMENV_LOG")
+
+	// The output is not opened until the logger is built
+	_, err := os.Stat(filepath.Join(dir, "app.log"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, _, err = lb.BuildE()
+	require.NoError(t, err)
+	files := lb.(*extendedLoggerBuilder).files
+	require.Len(t, files, 1)
+	require.NoError(t, lb.Close())
+
+	t.Setenv("FROMENV_LOG_OUTPUT", "stdout,"+dir)
+	logger, _, err := NewExtendedLoggerBuilder().FromEnv("FROMENV_LOG").BuildE()
+	assert.Nil(t, logger)
+	assert.ErrorContains(t, err, "FROMENV_LOG_OUTPUT: open")
+}
+
+func TestBuild_FromEnv_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv("FROMENV_LOG_OUTPUT", "stderr,"+path)
+	lb := NewExtendedLoggerBuilder().FromEnv("FROMENV_LOG")
+
+	// The files of every build are closed
+	logger1, _, err := lb.BuildE()
+	require.NoError(t, err)
+	logger2, _, err := lb.BuildE()
+	require.NoError(t, err)
+	logger1.Info("first logger")
+	logger2.Info("second logger")
+	files := lb.(*extendedLoggerBuilder).files
+	require.Len(t, files, 2)
+
+	require.NoError(t, lb.Close())
+	for _, file := range files {
+		_, err := file.Write([]byte("after close\n"))
+		assert.ErrorIs(t, err, os.ErrClosed)
+	}
+	assert.NoError(t, lb.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "first logger")
+	assert.Contains(t, string(content), "second logger")
+}
+
+func TestBuild_FromEnv_Repeated(t *testing.T) {
+	t.Setenv("FROMENV_LOG_LEVEL", "LOUD")
+	lb := NewExtendedLoggerBuilder().
+		WithWriter(&bytes.Buffer{}).
+		FromEnv("FROMENV_LOG")
+
+	// A later call replaces the errors of the earlier call
+	t.Setenv("FROMENV_LOG_LEVEL", "warn")
+	_, levelVar, err := lb.FromEnv("FROMENV_LOG").BuildE()
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+}
+
+func BenchmarkBuild_With(b *testing.B) {
+	b.Run("TextHandler", func(b *testing.B) {
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.With("a", 1, "b", "two").Info("m")
+		}
+	})
+	b.Run("Build", func(b *testing.B) {
+		logger, _ := NewLoggerBuilder().WithWriter(io.Discard).Build()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.With("a", 1, "b", "two").Info("m")
+		}
+	})
+}
@@ -0,0 +1,298 @@
+package slogx
+
+import (
+	"errors"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"weak"
+)
+
+// LoggerRegistry is an interface for controlling the levels of named loggers.  Logger names are dot separated
+// hierarchies, such as "payments.ledger", whose ancestors are "payments" and the root name "".  Call Register to
+// associate a slog.LevelVar with a name, or use ExtendedLoggerBuilder.WithName.  Registrations are held weakly, so they
+// are removed when the slog.LevelVar is garbage collected, or they can be removed with Unregister.  Call SetLevel to
+// set the level of a name and all of its descendants that do not have a level set for a closer name.  The level of a
+// registered logger is the level set for its own name, or else for its nearest ancestor with a level set.  While such
+// a level is set, it takes precedence over the levels set by a LevelManager the slog.LevelVar is enrolled in, which
+// are kept and applied again when the level is cleared or the slog.LevelVar is unregistered.
+type LoggerRegistry interface {
+	Register(name string, levelVar *slog.LevelVar) error
+	Unregister(levelVar *slog.LevelVar) bool
+	SetLevel(name string, level slog.Level) error
+	ClearLevel(name string) error
+	Level(name string) (slog.Level, bool)
+}
+
+// defaultLoggerRegistry is the default implementation of LoggerRegistry.
+type defaultLoggerRegistry struct {
+	mu      sync.Mutex
+	loggers map[string][]*registeredLogger
+	levels  map[string]slog.Level
+}
+
+// registeredLogger is a weak reference to a slog.LevelVar registered with a name, and whether its level is pinned to
+// the level set for the name.
+type registeredLogger struct {
+	name     string
+	levelVar weak.Pointer[slog.LevelVar]
+	pinned   bool
+}
+
+// pinnedLevels holds the slog.LevelVar objects whose level is set by a LoggerRegistry.  While a slog.LevelVar is
+// pinned, the levels a LevelManager sets for it are kept as its unpinned level, which is restored when no
+// registration pins it any more.
+var pinnedLevels = struct {
+	sync.Mutex
+	levels map[weak.Pointer[slog.LevelVar]]*pinnedLevel
+}{
+	levels: make(map[weak.Pointer[slog.LevelVar]]*pinnedLevel),
+}
+
+// pinnedLevel is the level of a pinned slog.LevelVar without the LoggerRegistry, and the number of registrations that
+// pin it.
+type pinnedLevel struct {
+	unpinned slog.Level
+	pins     int
+}
+
+// defaultLoggerRegistryInstance is the singleton instance of defaultLoggerRegistry.
+var defaultLoggerRegistryInstance = newLoggerRegistry()
+
+// GetLoggerRegistry returns the singleton instance of LoggerRegistry.
+func GetLoggerRegistry() LoggerRegistry {
+	return defaultLoggerRegistryInstance
+}
+
+// newLoggerRegistry returns a new, empty defaultLoggerRegistry.
+func newLoggerRegistry() *defaultLoggerRegistry {
+	return &defaultLoggerRegistry{
+		loggers: make(map[string][]*registeredLogger),
+		levels:  make(map[string]slog.Level),
+	}
+}
+
+// Register associates a slog.LevelVar with a logger name.  The level of the slog.LevelVar is not changed when neither
+// the name nor any of its ancestors has a level set.
+func (lr *defaultLoggerRegistry) Register(name string, levelVar *slog.LevelVar) error {
+	if levelVar == nil {
+		return errors.New("levelVar is required")
+	}
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if err := validateLoggerName(name); err != nil {
+		return err
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	logger := &registeredLogger{name: name, levelVar: weak.Make(levelVar)}
+	lr.loggers[name] = append(lr.loggers[name], logger)
+	lr.applyLevel(name, logger)
+
+	// Remove the registration once the slog.LevelVar is garbage collected
+	runtime.AddCleanup(levelVar, lr.remove, logger)
+	return nil
+}
+
+// Unregister removes every registration of the slog.LevelVar, and reports whether it was registered.  If a level was
+// set for its name, the level of the slog.LevelVar is restored to the level it would have without the LoggerRegistry.
+func (lr *defaultLoggerRegistry) Unregister(levelVar *slog.LevelVar) bool {
+	weakLevelVar := weak.Make(levelVar)
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	found := false
+	for name, loggers := range lr.loggers {
+		loggers = slices.DeleteFunc(loggers, func(logger *registeredLogger) bool {
+			if logger.levelVar != weakLevelVar {
+				return false
+			}
+			lr.unpin(logger)
+			return true
+		})
+		if len(loggers) < len(lr.loggers[name]) {
+			found = true
+		}
+		lr.setLoggers(name, loggers)
+	}
+	return found
+}
+
+// remove removes a registration.
+func (lr *defaultLoggerRegistry) remove(logger *registeredLogger) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	lr.unpin(logger)
+	lr.setLoggers(logger.name, slices.DeleteFunc(lr.loggers[logger.name], func(l *registeredLogger) bool {
+		return l == logger
+	}))
+}
+
+// setLoggers sets the registrations of a name, deleting the name if it has none.
+func (lr *defaultLoggerRegistry) setLoggers(name string, loggers []*registeredLogger) {
+	if len(loggers) == 0 {
+		delete(lr.loggers, name)
+	} else {
+		lr.loggers[name] = loggers
+	}
+}
+
+// SetLevel sets the level of the logger name and of its descendants that do not have a level set for a closer name.
+// Use the root name "" to set the level of all loggers.
+func (lr *defaultLoggerRegistry) SetLevel(name string, level slog.Level) error {
+	if err := validateLoggerName(name); err != nil {
+		return err
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	lr.levels[name] = level
+	lr.applyLevels(name)
+	return nil
+}
+
+// ClearLevel removes the level set for the logger name, so that it and its descendants inherit the level of the
+// nearest ancestor again.  Loggers left without a level set for their name or an ancestor are restored to the level
+// they would have without the LoggerRegistry, such as the last level set by their LevelManager.
+func (lr *defaultLoggerRegistry) ClearLevel(name string) error {
+	if err := validateLoggerName(name); err != nil {
+		return err
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	delete(lr.levels, name)
+	lr.applyLevels(name)
+	return nil
+}
+
+// Level returns the level set for the logger name or its nearest ancestor with a level set.  It returns false if
+// neither the name nor any of its ancestors has a level set.
+func (lr *defaultLoggerRegistry) Level(name string) (slog.Level, bool) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	return lr.configuredLevel(name)
+}
+
+// applyLevels updates the level of every logger registered with the name or one of its descendants.
+func (lr *defaultLoggerRegistry) applyLevels(name string) {
+	for loggerName, loggers := range lr.loggers {
+		if !isLoggerDescendant(loggerName, name) {
+			continue
+		}
+		for _, logger := range loggers {
+			lr.applyLevel(loggerName, logger)
+		}
+	}
+}
+
+// applyLevel pins the level of a registered logger to the level set for its name or an ancestor, or unpins it if no
+// level is set, unless its slog.LevelVar was garbage collected.
+func (lr *defaultLoggerRegistry) applyLevel(name string, logger *registeredLogger) {
+	levelVar := logger.levelVar.Value()
+	if levelVar == nil {
+		return
+	}
+	level, ok := lr.configuredLevel(name)
+	if !ok {
+		lr.unpin(logger)
+		return
+	}
+
+	pinnedLevels.Lock()
+	defer pinnedLevels.Unlock()
+
+	pinned := pinnedLevels.levels[logger.levelVar]
+	if pinned == nil {
+		pinned = &pinnedLevel{unpinned: levelVar.Level()}
+		pinnedLevels.levels[logger.levelVar] = pinned
+	}
+	if !logger.pinned {
+		logger.pinned = true
+		pinned.pins++
+	}
+	levelVar.Set(level)
+}
+
+// unpin removes the pin of a registered logger.  When no registration pins its slog.LevelVar any more, the unpinned
+// level is restored.
+func (lr *defaultLoggerRegistry) unpin(logger *registeredLogger) {
+	if !logger.pinned {
+		return
+	}
+	logger.pinned = false
+
+	pinnedLevels.Lock()
+	defer pinnedLevels.Unlock()
+
+	pinned := pinnedLevels.levels[logger.levelVar]
+	pinned.pins--
+	if pinned.pins > 0 {
+		return
+	}
+	delete(pinnedLevels.levels, logger.levelVar)
+	if levelVar := logger.levelVar.Value(); levelVar != nil {
+		levelVar.Set(pinned.unpinned)
+	}
+}
+
+// setUnpinnedLevel sets the level of a slog.LevelVar, or its unpinned level if it is pinned by a LoggerRegistry.  It
+// returns the previous level, and reports whether the level changed.
+func setUnpinnedLevel(levelVar *slog.LevelVar, level slog.Level) (slog.Level, bool) {
+	pinnedLevels.Lock()
+	defer pinnedLevels.Unlock()
+
+	if pinned := pinnedLevels.levels[weak.Make(levelVar)]; pinned != nil {
+		old := pinned.unpinned
+		pinned.unpinned = level
+		return old, old != level
+	}
+	old := levelVar.Level()
+	levelVar.Set(level)
+	return old, old != level
+}
+
+// configuredLevel returns the level set for the name or its nearest ancestor with a level set.
+func (lr *defaultLoggerRegistry) configuredLevel(name string) (slog.Level, bool) {
+	for {
+		if level, ok := lr.levels[name]; ok {
+			return level, true
+		}
+		if name == "" {
+			return 0, false
+		}
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			name = name[:i]
+		} else {
+			name = ""
+		}
+	}
+}
+
+// isLoggerDescendant reports whether the logger name is the ancestor name or one of its descendants.
+func isLoggerDescendant(name string, ancestor string) bool {
+	return ancestor == "" || name == ancestor || strings.HasPrefix(name, ancestor+".")
+}
+
+// validateLoggerName returns an error if the logger name has an empty segment, such as "payments..ledger".
+func validateLoggerName(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, segment := range strings.Split(name, ".") {
+		if segment == "" {
+			return errors.New("invalid logger name: " + name)
+		}
+	}
+	return nil
+}
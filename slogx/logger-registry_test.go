@@ -0,0 +1,253 @@
+package slogx
+
+import (
+	"bytes"
+	"log/slog"
+	"runtime"
+	"testing"
+	"time"
+	"weak"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// HELPERS
+
+func newRegisteredLevelVar(t *testing.T, registry LoggerRegistry, name string, level slog.Level) *slog.LevelVar {
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+	require.NoError(t, registry.Register(name, levelVar))
+	return levelVar
+}
+
+// TESTS
+
+func TestLoggerRegistry_Hierarchy(t *testing.T) {
+	registry := newLoggerRegistry()
+
+	payments := newRegisteredLevelVar(t, registry, "payments", slog.LevelInfo)
+	ledger := newRegisteredLevelVar(t, registry, "payments.ledger", slog.LevelWarn)
+	ledgerAudit := newRegisteredLevelVar(t, registry, "payments.ledger.audit", slog.LevelInfo)
+	paymentsAPI := newRegisteredLevelVar(t, registry, "paymentsapi", slog.LevelInfo)
+	orders := newRegisteredLevelVar(t, registry, "orders", slog.LevelError)
+
+	// Setting a parent level propagates to all descendants
+	require.NoError(t, registry.SetLevel("payments", slog.LevelDebug))
+	assert.Equal(t, slog.LevelDebug, payments.Level())
+	assert.Equal(t, slog.LevelDebug, ledger.Level())
+	assert.Equal(t, slog.LevelDebug, ledgerAudit.Level())
+	assert.Equal(t, slog.LevelInfo, paymentsAPI.Level())
+	assert.Equal(t, slog.LevelError, orders.Level())
+
+	// A child with its own level is not changed by its parent
+	require.NoError(t, registry.SetLevel("payments.ledger", slog.LevelError))
+	require.NoError(t, registry.SetLevel("payments", LevelTrace))
+	assert.Equal(t, LevelTrace, payments.Level())
+	assert.Equal(t, slog.LevelError, ledger.Level())
+	assert.Equal(t, slog.LevelError, ledgerAudit.Level())
+
+	// Clearing a level inherits from the nearest ancestor again
+	require.NoError(t, registry.ClearLevel("payments.ledger"))
+	assert.Equal(t, LevelTrace, ledger.Level())
+	assert.Equal(t, LevelTrace, ledgerAudit.Level())
+
+	// Clearing all levels restores the registered levels
+	require.NoError(t, registry.ClearLevel("payments"))
+	assert.Equal(t, slog.LevelInfo, payments.Level())
+	assert.Equal(t, slog.LevelWarn, ledger.Level())
+	assert.Equal(t, slog.LevelInfo, ledgerAudit.Level())
+}
+
+func TestLoggerRegistry_Root(t *testing.T) {
+	registry := newLoggerRegistry()
+
+	payments := newRegisteredLevelVar(t, registry, "payments", slog.LevelInfo)
+	orders := newRegisteredLevelVar(t, registry, "orders.api", slog.LevelInfo)
+	require.NoError(t, registry.SetLevel("orders", slog.LevelWarn))
+
+	require.NoError(t, registry.SetLevel("", slog.LevelDebug))
+	assert.Equal(t, slog.LevelDebug, payments.Level())
+	assert.Equal(t, slog.LevelWarn, orders.Level())
+
+	level, ok := registry.Level("payments.ledger")
+	assert.True(t, ok)
+	assert.Equal(t, slog.LevelDebug, level)
+}
+
+func TestLoggerRegistry_RegisterInheritsLevel(t *testing.T) {
+	registry := newLoggerRegistry()
+	require.NoError(t, registry.SetLevel("payments", slog.LevelDebug))
+
+	ledger := newRegisteredLevelVar(t, registry, "payments.ledger", slog.LevelInfo)
+	assert.Equal(t, slog.LevelDebug, ledger.Level())
+
+	// Loggers sharing a name are all updated
+	ledger2 := newRegisteredLevelVar(t, registry, "payments.ledger", slog.LevelWarn)
+	require.NoError(t, registry.SetLevel("payments.ledger", slog.LevelError))
+	assert.Equal(t, slog.LevelError, ledger.Level())
+	assert.Equal(t, slog.LevelError, ledger2.Level())
+
+	require.NoError(t, registry.ClearLevel("payments.ledger"))
+	require.NoError(t, registry.ClearLevel("payments"))
+	assert.Equal(t, slog.LevelInfo, ledger.Level())
+	assert.Equal(t, slog.LevelWarn, ledger2.Level())
+}
+
+func TestLoggerRegistry_Level(t *testing.T) {
+	registry := newLoggerRegistry()
+
+	_, ok := registry.Level("payments")
+	assert.False(t, ok)
+
+	require.NoError(t, registry.SetLevel("payments", slog.LevelDebug))
+	level, ok := registry.Level("payments.ledger.audit")
+	assert.True(t, ok)
+	assert.Equal(t, slog.LevelDebug, level)
+
+	_, ok = registry.Level("paymentsapi")
+	assert.False(t, ok)
+}
+
+func TestLoggerRegistry_Errors(t *testing.T) {
+	registry := newLoggerRegistry()
+
+	assert.EqualError(t, registry.Register("payments", nil), "levelVar is required")
+	assert.EqualError(t, registry.Register("", new(slog.LevelVar)), "name is required")
+	assert.EqualError(t, registry.Register("payments..ledger", new(slog.LevelVar)),
+		"invalid logger name: payments..ledger")
+	assert.Error(t, registry.SetLevel(".payments", slog.LevelDebug))
+	assert.Error(t, registry.ClearLevel("payments."))
+}
+
+func TestLoggerRegistry_Unregister(t *testing.T) {
+	registry := newLoggerRegistry()
+
+	ledger := newRegisteredLevelVar(t, registry, "payments.ledger", slog.LevelInfo)
+	require.NoError(t, registry.Register("orders", ledger))
+	other := newRegisteredLevelVar(t, registry, "payments.ledger", slog.LevelInfo)
+
+	assert.True(t, registry.Unregister(ledger))
+	assert.False(t, registry.Unregister(ledger))
+	assert.Len(t, registry.loggers["payments.ledger"], 1)
+	assert.NotContains(t, registry.loggers, "orders")
+
+	// An unregistered level is no longer changed
+	require.NoError(t, registry.SetLevel("", slog.LevelDebug))
+	assert.Equal(t, slog.LevelInfo, ledger.Level())
+	assert.Equal(t, slog.LevelDebug, other.Level())
+}
+
+func TestLoggerRegistry_GarbageCollected(t *testing.T) {
+	registry := newLoggerRegistry()
+
+	levelVar := newRegisteredLevelVar(t, registry, "tenant.kept", slog.LevelInfo)
+	names := []string{"tenant.a", "tenant.b", "tenant.c", "tenant.d", "tenant.e", "tenant.f", "tenant.g", "tenant.h"}
+	for _, name := range names {
+		newRegisteredLevelVar(t, registry, name, slog.LevelInfo)
+	}
+
+	// Garbage collected levels are removed.  Small objects can share memory with reachable objects, so not every
+	// level is guaranteed to be collected.
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		registry.mu.Lock()
+		defer registry.mu.Unlock()
+		return len(registry.loggers) <= 2
+	}, 5*time.Second, time.Millisecond)
+	registry.mu.Lock()
+	assert.Contains(t, registry.loggers, "tenant.kept")
+	registry.mu.Unlock()
+	runtime.KeepAlive(levelVar)
+}
+
+func TestBuild_WithName_EnrollmentFails(t *testing.T) {
+	_, _, err := NewExtendedLoggerBuilder().
+		WithWriter(&bytes.Buffer{}).
+		WithName("buildtest.unenrolled").
+		WithLevelEnvVar("BUILD_UNENROLLED_LEVEL").
+		WithLevelManager(failingLevelManager{}).
+		BuildE()
+	assert.EqualError(t, err, "levelManager: enrollment failed")
+
+	// The registration is removed when the build fails
+	registry := GetLoggerRegistry().(*defaultLoggerRegistry)
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	assert.NotContains(t, registry.loggers, "buildtest.unenrolled")
+}
+
+func TestLoggerRegistrySingleton(t *testing.T) {
+	assert.Equal(t, GetLoggerRegistry(), GetLoggerRegistry())
+}
+
+func TestBuild_WithName(t *testing.T) {
+	var buf bytes.Buffer
+	logger, levelVar := NewExtendedLoggerBuilder().
+		WithWriter(&buf).
+		WithName("buildtest.ledger").
+		Build()
+	assert.Equal(t, slog.LevelInfo, levelVar.Level())
+
+	require.NoError(t, GetLoggerRegistry().SetLevel("buildtest", slog.LevelDebug))
+	t.Cleanup(func() {
+		_ = GetLoggerRegistry().ClearLevel("buildtest")
+	})
+
+	logger.Debug("debug msg")
+	assert.Contains(t, buf.String(), "debug msg")
+}
+
+func TestBuild_WithName_WithManagedLevel(t *testing.T) {
+	t.Setenv("BUILDTEST_MANAGED_LEVEL", "WARN")
+	_, levelVar := NewExtendedLoggerBuilder().
+		WithWriter(&bytes.Buffer{}).
+		WithName("buildtest.managed").
+		WithLevelEnvVar("BUILDTEST_MANAGED_LEVEL").
+		WithManagedLevel().
+		Build()
+	levelManager := GetExtendedLevelManager()
+	t.Cleanup(func() {
+		levelManager.Unmanage(levelVar)
+		GetLoggerRegistry().Unregister(levelVar)
+	})
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+
+	// The level set with the LoggerRegistry takes precedence over the LevelManager
+	require.NoError(t, GetLoggerRegistry().SetLevel("buildtest", slog.LevelDebug))
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+	t.Setenv("BUILDTEST_MANAGED_LEVEL", "ERROR")
+	levelManager.UpdateLevels()
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+	require.NoError(t, levelManager.SetLevel("BUILDTEST_MANAGED_LEVEL", slog.LevelInfo))
+	assert.Equal(t, slog.LevelDebug, levelVar.Level())
+
+	// The last level set by the LevelManager is applied when the level is cleared
+	require.NoError(t, GetLoggerRegistry().ClearLevel("buildtest"))
+	assert.Equal(t, slog.LevelInfo, levelVar.Level())
+	levelManager.UpdateLevels()
+	assert.Equal(t, slog.LevelError, levelVar.Level())
+}
+
+func TestLoggerRegistry_Unregister_Pinned(t *testing.T) {
+	registry := newLoggerRegistry()
+
+	levelVar := newRegisteredLevelVar(t, registry, "payments", slog.LevelInfo)
+	require.NoError(t, registry.Register("orders", levelVar))
+
+	// A level pinned by two registrations is pinned until both are removed
+	require.NoError(t, registry.SetLevel("payments", slog.LevelDebug))
+	require.NoError(t, registry.SetLevel("orders", slog.LevelError))
+	var changes []levelChange
+	setLevelVar(levelVar, "PINNED_LEVEL", slog.LevelWarn, LevelSourceSet, &changes)
+	assert.Equal(t, slog.LevelError, levelVar.Level())
+	require.NoError(t, registry.ClearLevel("orders"))
+	assert.Equal(t, slog.LevelError, levelVar.Level())
+
+	// Unregistering restores the level set while it was pinned
+	assert.True(t, registry.Unregister(levelVar))
+	assert.Equal(t, slog.LevelWarn, levelVar.Level())
+	pinnedLevels.Lock()
+	assert.NotContains(t, pinnedLevels.levels, weak.Make(levelVar))
+	pinnedLevels.Unlock()
+}
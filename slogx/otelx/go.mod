@@ -0,0 +1,18 @@
+module github.com/Evernorth/slogx-go/slogx/otelx
+
+go 1.24.0
+
+require (
+	github.com/Evernorth/slogx-go v1.1.1-0.20261017054935-31970fbe099b
+	github.com/stretchr/testify v1.10.0
+	go.opentelemetry.io/otel v1.37.0
+	go.opentelemetry.io/otel/trace v1.37.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/Evernorth/slogx-go => ../..
@@ -0,0 +1,41 @@
+/*
+Package otelx provides OpenTelemetry integrations for slogx.  It is a separate module so that the slogx module does
+not depend on OpenTelemetry.
+
+See documentation: https://pkg.go.dev/github.com/Evernorth/slogx-go
+*/
+package otelx
+
+import (
+	"context"
+
+	"github.com/Evernorth/slogx-go/slogx"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceContext returns the slogx.TraceContext of the active OpenTelemetry span in the provided Context.  It returns
+// false if the Context does not have a valid span.  TraceContext is a slogx.TraceContextFunc and can be passed to
+// ExtendedLoggerBuilder.WithTraceContext.
+func TraceContext(ctx context.Context) (slogx.TraceContext, bool) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return slogx.TraceContext{}, false
+	}
+	return slogx.TraceContext{
+		TraceID:    spanContext.TraceID().String(),
+		SpanID:     spanContext.SpanID().String(),
+		TraceFlags: byte(spanContext.TraceFlags()),
+	}, true
+}
+
+// Baggage returns the value of the OpenTelemetry baggage member with the provided key from the provided Context.  It
+// returns false if there is no such member.  Baggage is a slogx.BaggageFunc and can be passed to
+// ExtendedLoggerBuilder.WithBaggage.
+func Baggage(ctx context.Context, key string) (string, bool) {
+	member := baggage.FromContext(ctx).Member(key)
+	if member.Key() == "" {
+		return "", false
+	}
+	return member.Value(), true
+}
@@ -0,0 +1,99 @@
+package otelx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/Evernorth/slogx-go/slogx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestSpanContext(t *testing.T) trace.SpanContext {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+}
+
+func TestTraceContext(t *testing.T) {
+	ctx := trace.ContextWithSpanContext(context.Background(), newTestSpanContext(t))
+
+	traceContext, ok := TraceContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, slogx.TraceContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		TraceFlags: 0x01,
+	}, traceContext)
+}
+
+func TestTraceContext_NoSpan(t *testing.T) {
+	_, ok := TraceContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestTraceContext_Logger(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger, _ := slogx.NewExtendedLoggerBuilder().
+		WithWriter(buffer).
+		WithFormat(slogx.FormatJSON).
+		WithTraceContext(TraceContext).
+		Build()
+
+	ctx := trace.ContextWithSpanContext(context.Background(), newTestSpanContext(t))
+	logger.InfoContext(ctx, "test msg", slog.String("rec", "r"))
+
+	assert.Contains(t, buffer.String(),
+		`"rec":"r","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}`)
+}
+
+func newTestBaggageContext(t *testing.T) context.Context {
+	tenant, err := baggage.NewMember("tenant", "t1")
+	require.NoError(t, err)
+	flag, err := baggage.NewMember("flag.beta", "on")
+	require.NoError(t, err)
+	bag, err := baggage.New(tenant, flag)
+	require.NoError(t, err)
+
+	return baggage.ContextWithBaggage(context.Background(), bag)
+}
+
+func TestBaggage(t *testing.T) {
+	ctx := newTestBaggageContext(t)
+
+	value, ok := Baggage(ctx, "tenant")
+	assert.True(t, ok)
+	assert.Equal(t, "t1", value)
+
+	_, ok = Baggage(ctx, "missing")
+	assert.False(t, ok)
+
+	_, ok = Baggage(context.Background(), "tenant")
+	assert.False(t, ok)
+}
+
+func TestBaggage_Logger(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	logger, _ := slogx.NewExtendedLoggerBuilder().
+		WithWriter(buffer).
+		WithFormat(slogx.FormatJSON).
+		WithBaggage(Baggage, "tenant").
+		Build()
+
+	ctx := slogx.ContextWithAttrs(newTestBaggageContext(t), slog.String("a", "1"))
+	logger.InfoContext(ctx, "test msg")
+
+	assert.Contains(t, buffer.String(), `"msg":"test msg","tenant":"t1","a":"1"}`)
+	assert.NotContains(t, buffer.String(), "flag.beta")
+}